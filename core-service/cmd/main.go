@@ -5,6 +5,7 @@ import (
 	"core-service/config"
 	"core-service/models"
 	"core-service/routes"
+	"core-service/utils"
 	"os"
 	"time"
 
@@ -15,6 +16,7 @@ import (
 	"go.uber.org/zap/zapcore"
 
 	server "core-service/controllers"
+	"core-service/internal/auth/oidc"
 	"core-service/internal/file"
 	"core-service/internal/observability/http"
 	"core-service/internal/observability/logging"
@@ -25,9 +27,11 @@ import (
 )
 
 func main() {
+	config.LoadEnv()
+
 	logger, err := logging.NewLogger(logging.Config{
 		Service: "core-service",
-		Env:     "dev",
+		Env:     config.Env(),
 		Level:   zapcore.InfoLevel,
 	})
 	if err != nil {
@@ -53,6 +57,19 @@ func main() {
 		logger.Fatal("metrics initialization failed", zap.Error(err))
 	}
 
+	ephemeralKeys, err := utils.InitSigningKeys()
+	if err != nil {
+		logger.Fatal("signing key initialization failed", zap.Error(err))
+	}
+	if ephemeralKeys {
+		// An ephemeral key is lost on restart and differs between
+		// instances, so it is only acceptable for a single dev instance.
+		if !config.IsDev() {
+			logger.Fatal("JWT_KEYS_DIR must be set when APP_ENV is not dev", zap.String("env", config.Env()))
+		}
+		logger.Warn("JWT_KEYS_DIR not set, signing tokens with an ephemeral key")
+	}
+
 	err = config.ConnectDB()
 	if err != nil {
 		logger.Fatal("database connection failed", zap.Error(err))
@@ -78,12 +95,73 @@ func main() {
 		logger.Fatal("Group metrics registration failed", zap.Error(err))
 	}
 
-	err = config.DB.AutoMigrate(&models.User{}, &models.Group{}, &models.GroupMember{}, &models.Task{}, &models.ChatMessage{}, &models.Attachment{})
+	// Checked before migrating so accounts that predate email verification
+	// can be grandfathered below.
+	hadVerifiedAt := config.DB.Migrator().HasColumn(&models.User{}, "verified_at")
+
+	err = config.DB.AutoMigrate(&models.User{}, &models.Group{}, &models.GroupMember{}, &models.Task{}, &models.ChatMessage{}, &models.Attachment{}, &models.ChatMessageEdit{}, &models.ChatReadCursor{}, &models.MessageReaction{}, &models.Poll{}, &models.PollOption{}, &models.PollVote{}, &models.ChatMention{}, &models.Notification{}, &models.Conversation{}, &models.ConversationParticipant{}, &models.Session{}, &models.RefreshToken{}, &models.PasswordResetToken{}, &models.EmailVerificationToken{}, &models.RecoveryCode{}, &models.MFAChallenge{}, &models.Identity{}, &models.OIDCLoginState{}, &models.AccessToken{})
 	if err != nil {
 		logger.Panic("automigrate failed", zap.Error(err))
 
 	}
 
+	// Group creators used to be plain admins; they own their groups now.
+	// Ownership cannot be granted afterwards, so the promotion only runs
+	// while no group has an owner yet, i.e. once, on the first boot with
+	// roles.
+	var owners int64
+	if err := config.DB.Model(&models.GroupMember{}).Where("role = ?", "owner").Limit(1).Count(&owners).Error; err != nil {
+		logger.Panic("group owner lookup failed", zap.Error(err))
+	}
+	if owners == 0 {
+		if err := config.DB.Exec(
+			`UPDATE group_members SET role = 'owner'
+			 FROM groups
+			 WHERE groups.id = group_members.group_id
+			   AND groups.created_by = group_members.user_id
+			   AND group_members.role = 'admin'`,
+		).Error; err != nil {
+			logger.Panic("group owner migration failed", zap.Error(err))
+		}
+	}
+
+	// Accounts created before email verification existed count as verified,
+	// so turning on REQUIRE_EMAIL_VERIFICATION does not lock them out.
+	if !hadVerifiedAt {
+		if err := config.DB.Exec(`UPDATE users SET verified_at = NOW() WHERE verified_at IS NULL`).Error; err != nil {
+			logger.Panic("email verification backfill failed", zap.Error(err))
+		}
+	}
+
+	// Index chat messages stored before full-text search existed.
+	if err := config.DB.Exec(models.RefreshSearchVectors + "m.search_vector IS NULL AND m.deleted_at IS NULL").Error; err != nil {
+		logger.Panic("chat search backfill failed", zap.Error(err))
+	}
+
+	if err := config.ConnectMailer(logger); err != nil {
+		logger.Fatal("mailer initialization failed", zap.Error(err))
+	}
+
+	if err := config.ConnectLockout(logger); err != nil {
+		logger.Fatal("lockout store initialization failed", zap.Error(err))
+	}
+	defer config.LockoutStore.Close()
+
+	publicURL := os.Getenv("PUBLIC_URL")
+	if publicURL == "" {
+		publicURL = "http://localhost:8080"
+	}
+
+	oidcConfigs, err := oidc.LoadConfigs(publicURL)
+	if err != nil {
+		logger.Fatal("oidc configuration invalid", zap.Error(err))
+	}
+	var oidcProviders []*oidc.Provider
+	for _, cfg := range oidcConfigs {
+		oidcProviders = append(oidcProviders, oidc.NewProvider(cfg, nil))
+		logger.Info("oidc provider configured", zap.String("provider", cfg.Name), zap.String("issuer", cfg.Issuer))
+	}
+
 	grpcConfig := file.FileClientConfig{
 		Addr:        os.Getenv("FILE_SERVICE_ADDR"),
 		InternalKey: os.Getenv("FILE_SERVICE_KEY"),
@@ -94,8 +172,14 @@ func main() {
 		logger.Panic("Failed to connect to gRPC server", zap.Error(err))
 	}
 
-	chatServer := server.NewServer(fileClient, logger)
+	if err := config.ConnectFanout(logger); err != nil {
+		logger.Fatal("chat fanout initialization failed", zap.Error(err))
+	}
+	defer config.ChatFanout.Close()
+
+	chatServer := server.NewServer(fileClient, config.ChatFanout, logger)
 	go chatServer.Run()
+	server.OnMembershipRevoked(chatServer.RevokeGroupAccess)
 
 	if err := metrics.InitChatMetrics(chatServer.ActiveConnections); err != nil {
 		logger.Fatal("Chat metrics registration failed", zap.Error(err))
@@ -104,6 +188,11 @@ func main() {
 	ChatHandler := server.NewChatHandler(chatServer)
 
 	r := gin.New()
+	// Login throttling keys on c.ClientIP(), so forwarded headers are only
+	// trusted from configured proxies.
+	if err := r.SetTrustedProxies(config.TrustedProxies()); err != nil {
+		logger.Fatal("invalid TRUSTED_PROXIES", zap.Error(err))
+	}
 
 	r.Use(gin.Recovery())
 	r.Use(otelgin.Middleware("core-service"))
@@ -120,11 +209,13 @@ func main() {
 		MaxAge: 12 * time.Hour,
 	}))
 	r.GET("/metrics", gin.WrapH(promhttp.Handler()))
-	routes.RegisterAuthRoutes(r)
+	routes.RegisterAuthRoutes(r, server.NewOIDCHandler(oidcProviders))
 	routes.RegisterGroupRoutes(r)
 	routes.RegisterUserRoutes(r)
+	routes.RegisterAdminRoutes(r)
 	routes.RegisterChatRoutes(r, ChatHandler)
 	routes.RegisterMaterialRoutes(r, fileClient)
+	routes.RegisterSearchRoutes(r)
 	r.Static("/uploads", "./uploads")
 
 	port := os.Getenv("PORT")
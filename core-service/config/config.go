@@ -13,10 +13,30 @@ import (
 
 var DB *gorm.DB
 
-func ConnectDB() error {
+// LoadEnv reads .env into the environment without overriding variables that
+// are already set. It must run before anything else reads configuration.
+func LoadEnv() {
 	_ = godotenv.Load()
+}
+
+// Env names the deployment from APP_ENV (dev, staging or prod), "dev" when
+// unset.
+func Env() string {
+	if env := os.Getenv("APP_ENV"); env != "" {
+		return env
+	}
+	return "dev"
+}
 
-	dsn := fmt.Sprintf(
+// IsDev reports whether this is a development deployment, where throwaway
+// signing keys are acceptable.
+func IsDev() bool {
+	return Env() == "dev"
+}
+
+// DSN is the Postgres connection string built from the DB_* variables.
+func DSN() string {
+	return fmt.Sprintf(
 		"host=%s user=%s password=%s dbname=%s port=%s sslmode=disable",
 		os.Getenv("DB_HOST"),
 		os.Getenv("DB_USER"),
@@ -24,8 +44,10 @@ func ConnectDB() error {
 		os.Getenv("DB_NAME"),
 		os.Getenv("DB_PORT"),
 	)
+}
 
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+func ConnectDB() error {
+	db, err := gorm.Open(postgres.Open(DSN()), &gorm.Config{})
 	if err != nil {
 		return err
 	}
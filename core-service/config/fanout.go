@@ -0,0 +1,34 @@
+package config
+
+import (
+	"fmt"
+	"os"
+
+	"core-service/internal/chat/fanout"
+
+	"go.uber.org/zap"
+)
+
+// ChatFanout carries chat events between instances.
+var ChatFanout fanout.Backend
+
+// ConnectFanout selects the chat fan-out backend from CHAT_FANOUT:
+// "postgres" delivers through LISTEN/NOTIFY so several instances can serve
+// the same rooms, "memory" (the default) only reaches clients of this
+// instance. It must run after ConnectDB.
+func ConnectFanout(log *zap.Logger) error {
+	switch driver := os.Getenv("CHAT_FANOUT"); driver {
+	case "postgres":
+		pg, err := fanout.NewPostgres(DB, DSN(), log)
+		if err != nil {
+			return err
+		}
+		log.Info("chat fanout using postgres", zap.String("node_id", pg.NodeID()))
+		ChatFanout = pg
+	case "", "memory":
+		ChatFanout = fanout.NewMemory()
+	default:
+		return fmt.Errorf("unknown CHAT_FANOUT %q", driver)
+	}
+	return nil
+}
@@ -0,0 +1,20 @@
+package config
+
+import (
+	"os"
+	"strings"
+)
+
+// TrustedProxies lists the proxies, from the comma-separated
+// TRUSTED_PROXIES (addresses or CIDRs), whose X-Forwarded-For header is
+// believed when working out a client's IP. With none set the connection's
+// own address is used, so clients cannot pick their IP by sending the header.
+func TrustedProxies() []string {
+	var proxies []string
+	for _, p := range strings.Split(os.Getenv("TRUSTED_PROXIES"), ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			proxies = append(proxies, p)
+		}
+	}
+	return proxies
+}
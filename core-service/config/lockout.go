@@ -0,0 +1,45 @@
+package config
+
+import (
+	"fmt"
+	"os"
+
+	"core-service/internal/auth/lockout"
+
+	"go.uber.org/zap"
+)
+
+// AccountLimiter throttles failed logins per account, IPLimiter per client
+// address.
+var (
+	AccountLimiter *lockout.Limiter
+	IPLimiter      *lockout.Limiter
+)
+
+// LockoutStore holds the failure counts behind both limiters.
+var LockoutStore lockout.Store
+
+// ConnectLockout selects the failure store from LOCKOUT_STORE: "postgres"
+// shares state through the database between instances, "memory" (the
+// default) keeps it in this process. It must run after ConnectDB.
+func ConnectLockout(log *zap.Logger) error {
+	var store lockout.Store
+
+	switch driver := os.Getenv("LOCKOUT_STORE"); driver {
+	case "postgres":
+		pg, err := lockout.NewPostgresStore(DB, log)
+		if err != nil {
+			return err
+		}
+		store = pg
+	case "", "memory":
+		store = lockout.NewMemoryStore()
+	default:
+		return fmt.Errorf("unknown LOCKOUT_STORE %q", driver)
+	}
+
+	LockoutStore = store
+	AccountLimiter = lockout.NewLimiter(store, lockout.AccountPolicy)
+	IPLimiter = lockout.NewLimiter(store, lockout.IPPolicy)
+	return nil
+}
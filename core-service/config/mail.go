@@ -0,0 +1,59 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"core-service/internal/mail"
+
+	"go.uber.org/zap"
+)
+
+var Mailer mail.Mailer
+
+// ConnectMailer selects the mailer from MAIL_DRIVER: "smtp" delivers through
+// SMTP_HOST, "log" only logs who was mailed and, with MAIL_OUTBOX_DIR set,
+// writes the messages to that directory. There is no default, so a deploy
+// cannot silently stop sending mail.
+func ConnectMailer(log *zap.Logger) error {
+	switch driver := os.Getenv("MAIL_DRIVER"); driver {
+	case "smtp":
+		if os.Getenv("SMTP_HOST") == "" || os.Getenv("MAIL_FROM") == "" {
+			return fmt.Errorf("MAIL_DRIVER=smtp requires SMTP_HOST and MAIL_FROM")
+		}
+		Mailer = mail.NewSMTPMailer(mail.SMTPConfig{
+			Host:     os.Getenv("SMTP_HOST"),
+			Port:     os.Getenv("SMTP_PORT"),
+			Username: os.Getenv("SMTP_USERNAME"),
+			Password: os.Getenv("SMTP_PASSWORD"),
+			From:     os.Getenv("MAIL_FROM"),
+		})
+	case "log":
+		m, err := mail.NewLogMailer(log, os.Getenv("MAIL_OUTBOX_DIR"))
+		if err != nil {
+			return err
+		}
+		Mailer = m
+	case "":
+		return fmt.Errorf("MAIL_DRIVER must be set to smtp or log")
+	default:
+		return fmt.Errorf("unknown MAIL_DRIVER %q", driver)
+	}
+	return nil
+}
+
+// FrontendURL is the base URL used for links in outgoing email.
+func FrontendURL() string {
+	if u := os.Getenv("FRONTEND_URL"); u != "" {
+		return u
+	}
+	return "http://localhost:3000"
+}
+
+// RequireEmailVerification reports whether unverified accounts are limited
+// to the routes needed to finish verification.
+func RequireEmailVerification() bool {
+	v, _ := strconv.ParseBool(os.Getenv("REQUIRE_EMAIL_VERIFICATION"))
+	return v
+}
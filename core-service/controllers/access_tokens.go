@@ -0,0 +1,199 @@
+package controllers
+
+import (
+	"net/http"
+	"time"
+
+	"core-service/config"
+	"core-service/internal/auth/scopes"
+	"core-service/models"
+	"core-service/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"core-service/internal/observability/logging"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+)
+
+var accessTokenTracer = otel.Tracer("controllers.access_token")
+
+const (
+	accessTokenDefaultDays = 30
+	accessTokenMaxDays     = 365
+	accessTokenMaxActive   = 20
+)
+
+func ListAccessTokens(c *gin.Context) {
+	ctx := c.Request.Context()
+	log := logging.Logger(ctx)
+
+	ctx, span := accessTokenTracer.Start(ctx, "access_token.list")
+	defer span.End()
+
+	userID := c.MustGet("user_id").(uuid.UUID)
+	span.SetAttributes(attribute.String("user.id", userID.String()))
+
+	var tokens []models.AccessToken
+	if err := config.DB.WithContext(ctx).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Order("created_at desc").
+		Find(&tokens).Error; err != nil {
+
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to list tokens")
+		log.Error("failed to list access tokens", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch tokens"})
+		return
+	}
+
+	span.SetAttributes(attribute.Int("tokens.count", len(tokens)))
+	span.SetStatus(codes.Ok, "tokens listed")
+	c.JSON(http.StatusOK, gin.H{"tokens": tokens, "available_scopes": scopes.Known()})
+}
+
+// CreateAccessToken issues a personal access token. The plaintext value is
+// only returned in this response.
+func CreateAccessToken(c *gin.Context) {
+	ctx := c.Request.Context()
+	log := logging.Logger(ctx)
+
+	ctx, span := accessTokenTracer.Start(ctx, "access_token.create")
+	defer span.End()
+
+	userID := c.MustGet("user_id").(uuid.UUID)
+	span.SetAttributes(attribute.String("user.id", userID.String()))
+
+	var input struct {
+		Name          string   `json:"name" binding:"required,max=100"`
+		Scopes        []string `json:"scopes" binding:"required"`
+		ExpiresInDays *int     `json:"expires_in_days"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		span.AddEvent("invalid_payload")
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	granted, err := scopes.Validate(input.Scopes)
+	if err != nil {
+		span.AddEvent("invalid_scopes")
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	days := accessTokenDefaultDays
+	if input.ExpiresInDays != nil {
+		days = *input.ExpiresInDays
+	}
+	if days < 1 || days > accessTokenMaxDays {
+		span.AddEvent("invalid_expiry")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "expires_in_days must be between 1 and 365"})
+		return
+	}
+
+	var active int64
+	if err := config.DB.WithContext(ctx).Model(&models.AccessToken{}).
+		Where("user_id = ? AND revoked_at IS NULL AND (expires_at IS NULL OR expires_at > ?)", userID, time.Now()).
+		Count(&active).Error; err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "token count failed")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create token"})
+		return
+	}
+	if active >= accessTokenMaxActive {
+		span.AddEvent("too_many_tokens")
+		c.JSON(http.StatusConflict, gin.H{"error": "Too many active tokens, revoke one first"})
+		return
+	}
+
+	plain, prefix, err := utils.GenerateAccessToken()
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "token generation failed")
+		log.Error("access token generation failed", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create token"})
+		return
+	}
+
+	now := time.Now()
+	expiresAt := now.AddDate(0, 0, days)
+	token := models.AccessToken{
+		UserID:    userID,
+		Name:      input.Name,
+		Prefix:    prefix,
+		TokenHash: utils.HashToken(plain),
+		Scopes:    granted,
+		CreatedAt: now,
+		ExpiresAt: &expiresAt,
+	}
+	if err := config.DB.WithContext(ctx).Create(&token).Error; err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "token store failed")
+		log.Error("failed to store access token", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create token"})
+		return
+	}
+
+	span.SetAttributes(attribute.String("token.id", token.ID.String()))
+	span.SetStatus(codes.Ok, "token created")
+	log.Info("access token created", zap.String("user_id", userID.String()), zap.String("token_id", token.ID.String()))
+
+	c.JSON(http.StatusCreated, gin.H{"token": plain, "access_token": token})
+}
+
+func RevokeAccessToken(c *gin.Context) {
+	ctx := c.Request.Context()
+	log := logging.Logger(ctx)
+
+	ctx, span := accessTokenTracer.Start(ctx, "access_token.revoke")
+	defer span.End()
+
+	userID := c.MustGet("user_id").(uuid.UUID)
+
+	tokenID, err := uuid.Parse(c.Param("tokenId"))
+	if err != nil {
+		span.AddEvent("invalid_token_id")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid token ID"})
+		return
+	}
+
+	span.SetAttributes(
+		attribute.String("user.id", userID.String()),
+		attribute.String("token.id", tokenID.String()),
+	)
+
+	result := config.DB.WithContext(ctx).Model(&models.AccessToken{}).
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL", tokenID, userID).
+		Update("revoked_at", time.Now())
+	if result.Error != nil {
+		span.RecordError(result.Error)
+		span.SetStatus(codes.Error, "token revoke failed")
+		log.Error("failed to revoke access token", zap.Error(result.Error))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke token"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		span.AddEvent("token_not_found")
+		c.JSON(http.StatusNotFound, gin.H{"error": "Token not found"})
+		return
+	}
+
+	span.SetStatus(codes.Ok, "token revoked")
+	log.Info("access token revoked", zap.String("user_id", userID.String()), zap.String("token_id", tokenID.String()))
+	c.JSON(http.StatusOK, gin.H{"message": "Token revoked"})
+}
+
+// revokeUserAccessTokens revokes all of the user's personal access tokens.
+// It runs alongside revokeUserSessions wherever a password change or account
+// removal must cut off every existing credential.
+func revokeUserAccessTokens(tx *gorm.DB, userID uuid.UUID) error {
+	return tx.Model(&models.AccessToken{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", time.Now()).Error
+}
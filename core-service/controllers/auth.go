@@ -5,11 +5,13 @@ import (
 	"core-service/models"
 	"core-service/utils"
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"go.uber.org/zap"
 	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
 
 	"core-service/internal/observability/logging"
 	"core-service/internal/observability/metrics"
@@ -61,16 +63,18 @@ func Register(c *gin.Context) {
 
 	span.SetAttributes(attribute.String("user.id", user.ID.String()))
 
-	token, err := utils.GenerateToken(user.ID)
-	if err != nil {
+	if _, err := startSession(c, ctx, user.ID); err != nil {
 		span.RecordError(err)
-		span.SetStatus(codes.Error, "token generation failed")
-		log.Error("token generation failed", zap.Error(err))
+		span.SetStatus(codes.Error, "session creation failed")
+		log.Error("session creation failed", zap.Error(err))
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
 		return
 	}
 
-	c.SetCookie("token", token, 3600*24, "/", "localhost", false, true)
+	if err := sendVerificationEmail(ctx, &user); err != nil {
+		span.RecordError(err)
+		log.Error("failed to send verification email", zap.String("user_id", user.ID.String()), zap.Error(err))
+	}
 
 	span.SetStatus(codes.Ok, "user registered")
 	log.Info("user registered", zap.String("user_id", user.ID.String()))
@@ -93,9 +97,17 @@ func Login(c *gin.Context) {
 		return
 	}
 
+	if loginThrottled(c, ctx, input.Email) {
+		metrics.AuthLoginFailure.Add(ctx, 1)
+		span.AddEvent("login_throttled")
+		log.Warn("login throttled", zap.String("email", input.Email), zap.String("ip", c.ClientIP()))
+		return
+	}
+
 	var user models.User
 	if err := config.DB.WithContext(ctx).Where("email = ?", input.Email).First(&user).Error; err != nil {
 		metrics.AuthLoginFailure.Add(ctx, 1)
+		recordLoginFailure(c, ctx, input.Email, nil)
 		span.AddEvent("invalid_credentials")
 		log.Warn("login failed", zap.String("email", input.Email))
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid email or password"})
@@ -104,6 +116,7 @@ func Login(c *gin.Context) {
 
 	if !utils.CheckPasswordHash(input.Password, user.Password) {
 		metrics.AuthLoginFailure.Add(ctx, 1)
+		recordLoginFailure(c, ctx, input.Email, &user)
 		span.AddEvent("invalid_credentials")
 		log.Warn("login failed", zap.String("user_id", user.ID.String()))
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid email or password"})
@@ -112,17 +125,35 @@ func Login(c *gin.Context) {
 
 	span.SetAttributes(attribute.String("user.id", user.ID.String()))
 
-	token, err := utils.GenerateToken(user.ID)
-	if err != nil {
+	if user.TOTPEnabledAt != nil {
+		mfaToken, err := createMFAChallenge(ctx, user.ID)
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "mfa challenge failed")
+			log.Error("mfa challenge creation failed", zap.Error(err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start login"})
+			return
+		}
+
+		span.AddEvent("mfa_required")
+		c.JSON(http.StatusOK, gin.H{
+			"mfa_required": true,
+			"mfa_token":    mfaToken,
+			"expires_in":   int(mfaChallengeTTL.Seconds()),
+		})
+		return
+	}
+
+	if _, err := startSession(c, ctx, user.ID); err != nil {
 		metrics.AuthLoginFailure.Add(ctx, 1)
 		span.RecordError(err)
-		span.SetStatus(codes.Error, "token generation failed")
-		log.Error("token generation failed", zap.Error(err))
+		span.SetStatus(codes.Error, "session creation failed")
+		log.Error("session creation failed", zap.Error(err))
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
 		return
 	}
 
-	c.SetCookie("token", token, 3600*24, "/", "localhost", false, true)
+	clearLoginFailures(ctx, input.Email)
 
 	span.SetStatus(codes.Ok, "login successful")
 	metrics.AuthLoginSuccess.Add(ctx, 1)
@@ -162,6 +193,14 @@ func ChangePassword(c *gin.Context) {
 		return
 	}
 
+	if user.Password == "" {
+		// Accounts created through an identity provider have no old
+		// password; they set one through the reset link instead.
+		span.AddEvent("no_password")
+		c.JSON(http.StatusConflict, gin.H{"error": "This account has no password yet, use the password reset link to set one"})
+		return
+	}
+
 	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(input.OldPassword)); err != nil {
 		span.AddEvent("invalid_old_password")
 		log.Warn("old password mismatch", zap.String("user_id", userID.String()))
@@ -178,7 +217,17 @@ func ChangePassword(c *gin.Context) {
 		return
 	}
 
-	if err := config.DB.WithContext(ctx).Model(&user).Update("password", string(hashedPassword)).Error; err != nil {
+	sessionID := c.MustGet("session_id").(uuid.UUID)
+
+	if err := config.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&user).Update("password", string(hashedPassword)).Error; err != nil {
+			return err
+		}
+		if err := revokeUserAccessTokens(tx, userID); err != nil {
+			return err
+		}
+		return revokeUserSessions(tx, userID, sessionID)
+	}); err != nil {
 		span.RecordError(err)
 		span.SetStatus(codes.Error, "db update failed")
 		log.Error("password update failed", zap.Error(err))
@@ -196,23 +245,26 @@ func Logout(c *gin.Context) {
 	log := logging.Logger(ctx)
 
 	userID := c.MustGet("user_id").(uuid.UUID)
+	sessionID := c.MustGet("session_id").(uuid.UUID)
 
-	token, err := utils.GenerateToken(userID)
-	if err != nil {
+	if err := config.DB.WithContext(ctx).Model(&models.Session{}).
+		Where("id = ?", sessionID).
+		Update("revoked_at", time.Now()).Error; err != nil {
 		log.Error(
-			"token generation failed",
+			"session revoke failed",
 			zap.String("user_id", userID.String()),
 			zap.Error(err),
 		)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to log out"})
 		return
 	}
 
-	c.SetCookie("token", token, 0, "/", "localhost", false, true)
+	clearSessionCookie(c)
 
 	log.Info(
 		"user logged out",
 		zap.String("user_id", userID.String()),
+		zap.String("session_id", sessionID.String()),
 	)
 	c.JSON(http.StatusOK, gin.H{"message": "Logout successful"})
 }
@@ -244,8 +296,15 @@ func DeleteUser(c *gin.Context) {
 		attribute.String("user.id", userID.String()),
 	)
 
-	if err := config.DB.WithContext(ctx).
-		Delete(&models.User{}, "id = ?", userID).Error; err != nil {
+	if err := config.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := revokeUserSessions(tx, userID, uuid.Nil); err != nil {
+			return err
+		}
+		if err := revokeUserAccessTokens(tx, userID); err != nil {
+			return err
+		}
+		return tx.Delete(&models.User{}, "id = ?", userID).Error
+	}); err != nil {
 
 		span.RecordError(err)
 		span.SetStatus(codes.Error, "user deletion failed")
@@ -262,5 +321,6 @@ func DeleteUser(c *gin.Context) {
 		zap.String("user_id", userID.String()),
 	)
 
+	clearSessionCookie(c)
 	c.JSON(http.StatusOK, gin.H{"message": "User deleted successfully"})
 }
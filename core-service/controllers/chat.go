@@ -5,9 +5,12 @@ import (
 	"core-service/config"
 	"core-service/models"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -15,6 +18,10 @@ import (
 	"github.com/gorilla/websocket"
 	"go.uber.org/zap"
 
+	"core-service/internal/chat/commands"
+	"core-service/internal/chat/fanout"
+	"core-service/internal/chat/protocol"
+	"core-service/internal/chat/rooms"
 	"core-service/internal/file"
 	"core-service/internal/observability/logging"
 	"core-service/internal/observability/metrics"
@@ -32,6 +39,10 @@ const (
 	pongWait       = 60 * time.Second
 	pingPeriod     = (pongWait * 9) / 10
 	maxMessageSize = 4096
+
+	// clientWorkQueue bounds the frames a client may have waiting for the
+	// database; further frames are refused until it drains.
+	clientWorkQueue = 64
 )
 
 var upgrader = websocket.Upgrader{
@@ -53,34 +64,27 @@ type ClientAttachmentDTO struct {
 	FileSize int64  `json:"file_size"`
 }
 
+// ClientMessage is the payload of any client frame; which fields are used
+// depends on Type. See internal/chat/protocol for the frame definitions.
 type ClientMessage struct {
-	Type        string                `json:"type"` // "join", "leave", "broadcast"
+	Type        string                `json:"-"`
+	ID          string                `json:"-"`
 	Room        string                `json:"room"`
 	Text        string                `json:"text"`
 	Attachments []ClientAttachmentDTO `json:"attachments"`
+	MessageID   string                `json:"message_id,omitempty"`
+	Emoji       string                `json:"emoji,omitempty"`
+	Question    string                `json:"question,omitempty"`
+	Options     []string              `json:"options,omitempty"`
+	Multiple    bool                  `json:"multiple,omitempty"`
+	Anonymous   bool                  `json:"anonymous,omitempty"`
+	ClosesAt    *time.Time            `json:"closes_at,omitempty"`
+	OptionIDs   []string              `json:"option_ids,omitempty"`
+	Before      string                `json:"before,omitempty"`
+	Limit       int                   `json:"limit,omitempty"`
 	client      *Client               `json:"-"`
 }
 
-type ServerAttachmentDTO struct {
-	ID       string `json:"id"`
-	FileURL  string `json:"file_url"`
-	FileType string `json:"file_type"`
-	FileSize int64  `json:"file_size"`
-}
-
-type ServerMessage struct {
-	ID          string                `json:"id"`
-	User        User                  `json:"user"`
-	Text        string                `json:"text"`
-	Attachments []ServerAttachmentDTO `json:"attachments"`
-	Timestamp   time.Time             `json:"timestamp"`
-}
-
-type User struct {
-	ID   string `json:"id"`
-	Name string `json:"name"`
-}
-
 type Client struct {
 	server   *Server
 	conn     *websocket.Conn
@@ -89,6 +93,21 @@ type Client struct {
 	UserID   string
 	Username string
 	log      *zap.Logger
+
+	// lastActive (unix nanos) and idle are shared by the pumps; away is
+	// Run's view of idle.
+	lastActive atomic.Int64
+	idle       atomic.Bool
+	away       bool
+
+	// sendMu guards writes to send against it being closed. send is only
+	// ever closed through closeSend.
+	sendMu     sync.Mutex
+	sendClosed bool
+
+	// work runs the client's frames that need the database, in order and
+	// off Run. Only Run sends to it and closes it.
+	work chan func()
 }
 
 type Hub struct {
@@ -99,6 +118,10 @@ type Hub struct {
 	register   chan *Client
 	unregister chan *Client
 	stop       chan bool
+
+	// conversationRecorded is set once a direct room's conversation is
+	// known to exist.
+	conversationRecorded atomic.Bool
 }
 
 func newHub(roomID string, server *Server) *Hub {
@@ -132,17 +155,15 @@ func (h *Hub) run() {
 			}
 		case message := <-h.broadcast:
 			for client := range h.clients {
-				select {
-				case client.send <- message:
-				default:
-					close(client.send)
+				if !client.trySend(message) {
+					client.closeSend()
 					delete(h.clients, client)
 				}
 			}
 		case <-h.stop:
 			for client := range h.clients {
 				delete(h.clients, client)
-				close(client.send)
+				client.closeSend()
 			}
 			return
 		}
@@ -155,21 +176,38 @@ type Server struct {
 	broadcast  chan *ClientMessage
 	register   chan *Client
 	unregister chan *Client
+	revoke     chan roomRevocation
+	status     chan statusChange
+	presence   chan presenceUpdate
+	joined     chan joinAck
+	typingMu   sync.Mutex
+	typing     map[typingKey]*typingState
+	commands   *commands.Registry
 	mutex      sync.RWMutex
 	fileClient *file.Client
+	fanout     fanout.Backend
 	log        *zap.Logger
 }
 
-func NewServer(fileClient *file.Client, log *zap.Logger) *Server {
-	return &Server{
+func NewServer(fileClient *file.Client, backend fanout.Backend, log *zap.Logger) *Server {
+	s := &Server{
 		hubs:       make(map[string]*Hub),
 		clients:    make(map[*Client]bool),
 		broadcast:  make(chan *ClientMessage),
 		register:   make(chan *Client),
 		unregister: make(chan *Client),
+		revoke:     make(chan roomRevocation),
+		status:     make(chan statusChange),
+		presence:   make(chan presenceUpdate, presenceQueueSize),
+		joined:     make(chan joinAck),
+		typing:     make(map[typingKey]*typingState),
+		commands:   commands.NewRegistry(),
 		fileClient: fileClient,
+		fanout:     backend,
 		log:        log,
 	}
+	s.registerBuiltinCommands()
+	return s
 }
 
 func (s *Server) ActiveConnections() int {
@@ -206,56 +244,30 @@ func (s *Server) removeHub(roomID string) {
 	}
 }
 
-func (s *Server) fetchHistory(client *Client, roomID string) {
+// toServerMessage converts a stored message for clients, signing download
+// URLs for its attachments.
+func (s *Server) toServerMessage(msg *models.ChatMessage) *protocol.Message {
 	ctx := context.Background()
+	room, _ := rooms.FromKey(msg.RoomID)
 
-	ctx, span := chatTracer.Start(ctx, "chat.history.fetch")
-	span.SetAttributes(
-		attribute.String("room.id", roomID),
-		attribute.String("user.id", client.UserID),
-	)
-	defer span.End()
-
-	var messages []models.ChatMessage
-
-	err := config.DB.WithContext(ctx).Preload("User").
-		Preload("Attachments").
-		Where("room_id = ?", roomID).
-		Order("timestamp desc").
-		Limit(50).
-		Find(&messages).Error
-
-	if err != nil {
-
-		span.RecordError(err)
-		span.SetStatus(codes.Error, "db query failed")
-
-		client.log.Error(
-			"failed to fetch chat history",
-			zap.String("room_id", roomID),
-			zap.Error(err),
-		)
-		return
+	parentID := ""
+	if msg.ParentID != nil {
+		parentID = msg.ParentID.String()
 	}
 
-	client.log.Info(
-		"chat history loaded",
-		zap.String("room_id", roomID),
-		zap.Int("count", len(messages)),
-	)
-	span.SetAttributes(attribute.Int("messages.count", len(messages)))
-
-	for i := len(messages) - 1; i >= 0; i-- {
-		msg := messages[i]
-		s.sendSingleMessageToClient(client, &msg)
-
+	if msg.DeletedAt != nil {
+		return &protocol.Message{
+			ID:        msg.ID.String(),
+			Room:      room.String(),
+			User:      protocol.Author{ID: msg.UserID.String(), Name: msg.User.Username},
+			Kind:      msg.Kind,
+			Timestamp: msg.Timestamp,
+			Deleted:   true,
+			ParentID:  parentID,
+		}
 	}
-}
-
-func (s *Server) sendSingleMessageToClient(client *Client, msg *models.ChatMessage) {
-	ctx := context.Background()
 
-	var attDTOs []ServerAttachmentDTO
+	var attDTOs []protocol.Attachment
 
 	for _, att := range msg.Attachments {
 		_, span := chatTracer.Start(ctx, "url.download.generate")
@@ -271,7 +283,7 @@ func (s *Server) sendSingleMessageToClient(client *Client, msg *models.ChatMessa
 
 		span.End()
 
-		attDTOs = append(attDTOs, ServerAttachmentDTO{
+		attDTOs = append(attDTOs, protocol.Attachment{
 			ID:       att.ID.String(),
 			FileURL:  downloadURL,
 			FileType: att.FileType,
@@ -279,25 +291,66 @@ func (s *Server) sendSingleMessageToClient(client *Client, msg *models.ChatMessa
 		})
 	}
 
-	serverMsg := &ServerMessage{
+	pinnedBy := ""
+	if msg.PinnedBy != nil {
+		pinnedBy = msg.PinnedBy.String()
+	}
+
+	return &protocol.Message{
 		ID:          msg.ID.String(),
-		User:        User{ID: msg.UserID.String(), Name: msg.User.Username},
+		Room:        room.String(),
+		User:        protocol.Author{ID: msg.UserID.String(), Name: msg.User.Username},
+		Kind:        msg.Kind,
 		Text:        msg.Text,
 		Attachments: attDTOs,
 		Timestamp:   msg.Timestamp,
+		EditedAt:    msg.EditedAt,
+		ParentID:    parentID,
+		PinnedAt:    msg.PinnedAt,
+		PinnedBy:    pinnedBy,
+	}
+}
+
+// broadcastEvent sends a frame to everyone connected to the room on any
+// instance.
+func (s *Server) broadcastEvent(roomKey, frameType string, payload interface{}) {
+	msgBytes, err := protocol.Encode(frameType, "", payload)
+	if err != nil {
+		s.log.Error("failed to encode room event", zap.Error(err))
+		return
+	}
+	if err := s.fanout.Publish(context.Background(), fanout.Event{Room: roomKey, Frame: msgBytes}); err != nil {
+		s.log.Error("failed to publish room event", zap.String("room_id", roomKey), zap.Error(err))
+	}
+}
+
+// deliver hands an event published by any instance to the local clients of
+// its room.
+func (s *Server) deliver(ev fanout.Event) {
+	if ev.RevokeUser != "" {
+		// Revocations change client state owned by Run.
+		go func() { s.revoke <- roomRevocation{roomKey: ev.Room, userID: ev.RevokeUser} }()
+		return
 	}
 
-	msgBytes, _ := json.Marshal(serverMsg)
-	client.send <- msgBytes
+	hub, ok := s.getHub(ev.Room)
+	if !ok {
+		return
+	}
+	select {
+	case hub.broadcast <- []byte(ev.Frame):
+	case <-hub.stop:
+	}
 }
 
-func (s *Server) saveMessage(client *Client, roomID string, text string, clientAtts []ClientAttachmentDTO) (*models.ChatMessage, error) {
+func (s *Server) saveMessage(client *Client, roomID string, kind string, text string, clientAtts []ClientAttachmentDTO, parentID *uuid.UUID) (*models.ChatMessage, error) {
 	ctx := context.Background()
 
 	ctx, span := chatTracer.Start(ctx, "chat.message.save")
 	span.SetAttributes(
 		attribute.String("room.id", roomID),
 		attribute.String("user.id", client.UserID),
+		attribute.String("message.kind", kind),
 		attribute.Int("attachments.count", len(clientAtts)),
 	)
 	defer span.End()
@@ -312,13 +365,16 @@ func (s *Server) saveMessage(client *Client, roomID string, text string, clientA
 	msg := &models.ChatMessage{
 		RoomID:    roomID,
 		UserID:    userID,
+		Kind:      kind,
 		Text:      text,
 		Timestamp: time.Now(),
+		ParentID:  parentID,
 	}
 
 	for _, att := range clientAtts {
 		msg.Attachments = append(msg.Attachments, models.Attachment{
 			FileID:   att.FileID,
+			FileName: att.FileName,
 			FileType: att.FileType,
 			FileSize: att.FileSize,
 		})
@@ -341,10 +397,20 @@ func (s *Server) saveMessage(client *Client, roomID string, text string, clientA
 		return nil, err
 	}
 
+	s.recordMentions(ctx, msg)
+
 	return msg, err
 }
 
 func (s *Server) Run() {
+	s.fanout.Subscribe(s.deliver)
+	go s.runPresence()
+
+	typingSweep := time.NewTicker(time.Second)
+	defer typingSweep.Stop()
+	pollSweep := time.NewTicker(pollSweepInterval)
+	defer pollSweep.Stop()
+
 	for {
 		select {
 		case client := <-s.register:
@@ -357,78 +423,192 @@ func (s *Server) Run() {
 					if hub, ok := s.getHub(roomID); ok {
 						hub.unregister <- client
 					}
+					s.leavePresence(client, roomID)
 				}
-				close(client.send)
+				close(client.work)
+				client.closeSend()
 			}
 
+		case r := <-s.revoke:
+			s.applyRevocation(r)
+
+		case change := <-s.status:
+			s.applyStatusChange(change)
+
+		case a := <-s.joined:
+			s.ackJoin(a)
+
+		case now := <-typingSweep.C:
+			s.expireTyping(now)
+
+		case now := <-pollSweep.C:
+			go s.closeExpiredPolls(now)
+
 		case clientMsg := <-s.broadcast:
 			client := clientMsg.client
+			room, _ := rooms.FromKey(clientMsg.Room)
 
 			switch clientMsg.Type {
-			case "join":
+			case protocol.TypeJoin:
 				span := trace.SpanFromContext(context.Background())
 				span.AddEvent("room.joined", trace.WithAttributes(attribute.String("room.id", clientMsg.Room)))
+				if client.rooms[clientMsg.Room] {
+					s.presence <- presenceUpdate{
+						userID:  client.UserID,
+						roomKey: clientMsg.Room,
+						joined:  &joinAck{client: client, id: clientMsg.ID, roomKey: clientMsg.Room},
+					}
+					continue
+				}
 				hub := s.getOrCreateHub(clientMsg.Room)
 				hub.register <- client
 				client.rooms[clientMsg.Room] = true
-				go s.fetchHistory(client, clientMsg.Room)
+				s.joinPresence(client, clientMsg.ID, clientMsg.Room)
+
+			case protocol.TypeHistory:
+				go s.sendHistoryPage(client, clientMsg.ID, clientMsg.Room, clientMsg.Before, clientMsg.Limit)
+
+			case protocol.TypeEdit:
+				s.dispatch(client, clientMsg, room, func() { s.editMessage(client, clientMsg) })
 
-			case "leave":
+			case protocol.TypeDelete:
+				s.dispatch(client, clientMsg, room, func() { s.deleteMessage(client, clientMsg) })
+
+			case protocol.TypeLeave:
 				span := trace.SpanFromContext(context.Background())
 				span.AddEvent("room.leave", trace.WithAttributes(attribute.String("room.id", clientMsg.Room)))
-				if hub, ok := s.getHub(clientMsg.Room); ok {
-					hub.unregister <- client
+				if client.rooms[clientMsg.Room] {
+					if hub, ok := s.getHub(clientMsg.Room); ok {
+						hub.unregister <- client
+					}
 					delete(client.rooms, clientMsg.Room)
+					s.leavePresence(client, clientMsg.Room)
 				}
+				client.ack(clientMsg.ID, protocol.Ack{Room: room.String()})
 
-			case "broadcast":
-				span := trace.SpanFromContext(context.Background())
-				span.AddEvent("room.joined", trace.WithAttributes(attribute.String("room.id", clientMsg.Room)))
-				if hub, ok := s.getHub(clientMsg.Room); ok {
-					if _, inRoom := hub.clients[client]; inRoom {
-
-						savedMsg, err := s.saveMessage(client, clientMsg.Room, clientMsg.Text, clientMsg.Attachments)
-						if err != nil {
-							client.log.Error(
-								"failed to save message",
-								zap.String("room_id", clientMsg.Room),
-								zap.Error(err),
-							)
-							continue
-						}
-
-						var attDTOs []ServerAttachmentDTO
-						for _, att := range savedMsg.Attachments {
-							url, err := s.fileClient.GenerateDownloadURL(att.FileID)
-							if err != nil {
-								continue
-							}
-							attDTOs = append(attDTOs, ServerAttachmentDTO{
-								ID:       att.ID.String(),
-								FileURL:  url,
-								FileType: att.FileType,
-								FileSize: att.FileSize,
-							})
-						}
-
-						serverMsg := &ServerMessage{
-							ID:          savedMsg.ID.String(),
-							User:        User{ID: savedMsg.UserID.String(), Name: savedMsg.User.Username},
-							Text:        savedMsg.Text,
-							Attachments: attDTOs,
-							Timestamp:   savedMsg.Timestamp,
-						}
-
-						msgBytes, _ := json.Marshal(serverMsg)
-						hub.broadcast <- msgBytes
+			case protocol.TypeRead:
+				s.dispatch(client, clientMsg, room, func() { s.markRead(client, clientMsg) })
 
-					}
+			case protocol.TypeReact, protocol.TypeUnreact:
+				s.dispatch(client, clientMsg, room, func() { s.react(client, clientMsg) })
+
+			case protocol.TypePin, protocol.TypeUnpin:
+				s.dispatch(client, clientMsg, room, func() { s.pin(client, clientMsg) })
+
+			case protocol.TypePoll:
+				if !client.rooms[clientMsg.Room] {
+					client.sendError(clientMsg.ID, protocol.CodeNotJoined, room.String(), "Join the room before sending messages")
+					continue
+				}
+				s.dispatch(client, clientMsg, room, func() { s.postPoll(client, clientMsg) })
+
+			case protocol.TypeVote:
+				s.dispatch(client, clientMsg, room, func() { s.vote(client, clientMsg) })
+
+			case protocol.TypeClosePoll:
+				s.dispatch(client, clientMsg, room, func() { s.closePoll(client, clientMsg) })
+
+			case protocol.TypeTypingStart:
+				if !client.rooms[clientMsg.Room] {
+					client.sendError(clientMsg.ID, protocol.CodeNotJoined, room.String(), "Join the room first")
+					continue
 				}
+				s.startTyping(client, clientMsg.Room)
+				client.ack(clientMsg.ID, protocol.Ack{Room: room.String()})
+
+			case protocol.TypeTypingStop:
+				s.stopTyping(client, clientMsg.Room)
+				client.ack(clientMsg.ID, protocol.Ack{Room: room.String()})
+
+			case protocol.TypeBroadcast, protocol.TypeReply, protocol.TypeAnnounce:
+				if !client.rooms[clientMsg.Room] {
+					client.sendError(clientMsg.ID, protocol.CodeNotJoined, room.String(), "Join the room before sending messages")
+					continue
+				}
+				if clientMsg.Type == protocol.TypeBroadcast && commands.IsCommand(clientMsg.Text) {
+					s.dispatch(client, clientMsg, room, func() { s.runCommand(client, clientMsg, room) })
+					continue
+				}
+				clientMsg.Text = commands.Unescape(clientMsg.Text)
+				if strings.TrimSpace(clientMsg.Text) == "" && len(clientMsg.Attachments) == 0 {
+					client.sendError(clientMsg.ID, protocol.CodeInvalidFrame, room.String(), "Message cannot be empty")
+					continue
+				}
+
+				s.dispatch(client, clientMsg, room, func() { s.postMessage(client, clientMsg, room) })
 			}
 		}
 	}
 }
 
+// dispatch queues fn on the client's worker so its database work does not
+// hold up Run. Frames from one client still complete in the order they were
+// sent. It runs on Run.
+func (s *Server) dispatch(client *Client, req *ClientMessage, room rooms.Room, fn func()) {
+	select {
+	case client.work <- fn:
+	default:
+		client.sendError(req.ID, protocol.CodeInternal, room.String(), "Too many requests in progress, try again")
+	}
+}
+
+// workPump runs the client's queued frames until Run closes work.
+func (c *Client) workPump() {
+	for fn := range c.work {
+		fn()
+	}
+}
+
+// postMessage saves and broadcasts a message frame. It runs on the client's
+// worker, off Run.
+func (s *Server) postMessage(client *Client, clientMsg *ClientMessage, room rooms.Room) {
+	if room.Kind == rooms.Direct {
+		s.recordDirectRoom(client, room)
+	}
+
+	var parentID *uuid.UUID
+	if clientMsg.Type == protocol.TypeReply {
+		parent, err := replyRoot(context.Background(), clientMsg.Room, clientMsg.MessageID)
+		if err == nil && parent.Kind == models.MessageAnnouncement {
+			err = errMessageNotEditable
+		}
+		if err != nil {
+			s.reportMessageChangeError(client, clientMsg.ID, room, err, "reply to")
+			return
+		}
+		parentID = &parent.ID
+	}
+
+	kind := models.MessageText
+	if clientMsg.Type == protocol.TypeAnnounce {
+		kind = models.MessageAnnouncement
+	}
+	savedMsg, err := s.saveMessage(client, clientMsg.Room, kind, clientMsg.Text, clientMsg.Attachments, parentID)
+	if err != nil {
+		client.log.Error(
+			"failed to save message",
+			zap.String("room_id", clientMsg.Room),
+			zap.Error(err),
+		)
+		client.sendError(clientMsg.ID, protocol.CodeInternal, room.String(), "Could not send message")
+		return
+	}
+
+	s.stopTyping(client, clientMsg.Room)
+	s.broadcastEvent(clientMsg.Room, protocol.TypeMessage, s.toServerMessage(savedMsg))
+	if parentID != nil {
+		s.broadcastThreadUpdate(context.Background(), clientMsg.Room, *parentID)
+	}
+	if kind == models.MessageAnnouncement {
+		s.notifyAnnouncement(context.Background(), savedMsg)
+	}
+	client.ack(clientMsg.ID, protocol.Ack{
+		Room:      room.String(),
+		MessageID: savedMsg.ID.String(),
+		Timestamp: &savedMsg.Timestamp,
+	})
+}
+
 func (c *Client) readPump() {
 	defer func() {
 		c.log.Info("client disconnected")
@@ -446,17 +626,56 @@ func (c *Client) readPump() {
 			return
 		}
 
-		var clientMsg ClientMessage
-		if err := json.Unmarshal(message, &clientMsg); err != nil {
-			c.log.Warn("invalid client message", zap.Error(err))
+		c.touch()
+		clientMsg, ok := c.decodeFrame(message)
+		if !ok {
+			continue
+		}
+		if !c.authorizeFrame(clientMsg) {
 			continue
 		}
-		clientMsg.client = c
-		c.server.broadcast <- &clientMsg
+		c.server.broadcast <- clientMsg
 
 	}
 }
 
+// decodeFrame unwraps a client frame into a ClientMessage. It reports false
+// after sending an error frame to the client.
+func (c *Client) decodeFrame(raw []byte) (*ClientMessage, bool) {
+	env, err := protocol.Decode(raw)
+	if err != nil {
+		id := ""
+		if env != nil {
+			id = env.ID
+		}
+		c.log.Warn("invalid client frame", zap.Error(err))
+		if errors.Is(err, protocol.ErrUnsupportedVersion) {
+			c.sendError(id, protocol.CodeUnsupportedVersion, "",
+				fmt.Sprintf("Protocol version %d is not supported, use %d", env.V, protocol.Version))
+		} else {
+			c.sendError(id, protocol.CodeInvalidFrame, "", err.Error())
+		}
+		return nil, false
+	}
+
+	if !protocol.IsClientType(env.Type) {
+		c.sendError(env.ID, protocol.CodeUnknownType, "", fmt.Sprintf("Unknown frame type %q", env.Type))
+		return nil, false
+	}
+
+	clientMsg := &ClientMessage{}
+	if len(env.Payload) > 0 {
+		if err := json.Unmarshal(env.Payload, clientMsg); err != nil {
+			c.sendError(env.ID, protocol.CodeInvalidFrame, "", "Invalid payload for "+env.Type)
+			return nil, false
+		}
+	}
+	clientMsg.Type = env.Type
+	clientMsg.ID = env.ID
+	clientMsg.client = c
+	return clientMsg, true
+}
+
 func (c *Client) writePump() {
 	ticker := time.NewTicker(pingPeriod)
 
@@ -481,7 +700,8 @@ func (c *Client) writePump() {
 				return
 			}
 
-		case <-ticker.C:
+		case now := <-ticker.C:
+			c.checkIdle(now)
 			c.conn.SetWriteDeadline(time.Now().Add(writeWait))
 			if err := c.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
 				c.log.Warn("websocket ping failed", zap.Error(err))
@@ -523,17 +743,20 @@ func (h *ChatHandler) HandleConnection(c *gin.Context) {
 		server:   h.server,
 		conn:     conn,
 		send:     make(chan []byte, 256),
+		work:     make(chan func(), clientWorkQueue),
 		rooms:    make(map[string]bool),
 		UserID:   userID.String(),
 		Username: user.Username,
 		log:      log,
 	}
+	client.lastActive.Store(time.Now().UnixNano())
 
 	h.server.register <- client
 	span.AddEvent("client.registered")
 
 	go client.writePump()
 	go client.readPump()
+	go client.workPump()
 
 	span.SetStatus(codes.Ok, "connection established")
 }
@@ -0,0 +1,239 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+
+	"core-service/config"
+	"core-service/internal/chat/fanout"
+	"core-service/internal/chat/protocol"
+	"core-service/internal/chat/rooms"
+	"core-service/internal/permissions"
+	"core-service/models"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+var errRoomForbidden = errors.New("not allowed in this room")
+
+// sendError rejects the client frame with the given id (empty for errors
+// not caused by a specific frame).
+func (c *Client) sendError(id, code, room, message string) {
+	c.sendFrame(protocol.TypeError, id, protocol.Error{Code: code, Message: message, Room: room})
+}
+
+// ack confirms a client frame. Frames sent without an id are not acked.
+func (c *Client) ack(id string, ack protocol.Ack) {
+	if id == "" {
+		return
+	}
+	c.sendFrame(protocol.TypeAck, id, ack)
+}
+
+// sendFrame queues a frame for this client only, dropping it if the client
+// is not keeping up.
+func (c *Client) sendFrame(frameType, id string, payload interface{}) {
+	frame, err := protocol.Encode(frameType, id, payload)
+	if err != nil {
+		c.log.Error("failed to encode frame", zap.Error(err))
+		return
+	}
+	if !c.trySend(frame) {
+		c.log.Warn("dropping frame for slow or disconnected client")
+	}
+}
+
+// trySend queues a frame without blocking. It reports false if the client's
+// buffer is full or the client has been disconnected. Any goroutine may call
+// it.
+func (c *Client) trySend(frame []byte) bool {
+	c.sendMu.Lock()
+	defer c.sendMu.Unlock()
+	if c.sendClosed {
+		return false
+	}
+	select {
+	case c.send <- frame:
+		return true
+	default:
+		return false
+	}
+}
+
+// closeSend closes the client's send channel, which makes writePump end the
+// connection. Hubs and Run may both call it; only the first call closes.
+func (c *Client) closeSend() {
+	c.sendMu.Lock()
+	defer c.sendMu.Unlock()
+	if !c.sendClosed {
+		c.sendClosed = true
+		close(c.send)
+	}
+}
+
+// authorizeRoom checks that the user may use the room with the given
+// permission. Group rooms require a joined membership whose role grants perm;
+// direct rooms require the two participants to share a group, and
+// conversation rooms that the user is a participant. Outside groups there
+// are no roles, so only reading and posting are ever allowed.
+func authorizeRoom(ctx context.Context, userID uuid.UUID, room rooms.Room, perm permissions.Permission) error {
+	ctx, span := chatTracer.Start(ctx, "chat.room.authorize")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("room.id", room.String()),
+		attribute.String("user.id", userID.String()),
+		attribute.String("permission", string(perm)),
+	)
+
+	if room.Kind != rooms.Group && perm != permissions.ChatRead && perm != permissions.ChatPost {
+		return errRoomForbidden
+	}
+
+	switch room.Kind {
+	case rooms.Group:
+		var member models.GroupMember
+		err := config.DB.WithContext(ctx).First(
+			&member,
+			"group_id = ? AND user_id = ? AND status = ?",
+			room.GroupID, userID, "joined",
+		).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errRoomForbidden
+		}
+		if err != nil {
+			span.RecordError(err)
+			return err
+		}
+		if !permissions.Has(member.Role, perm) {
+			return errRoomForbidden
+		}
+		return nil
+
+	case rooms.Direct:
+		shared, err := mutualGroupIDs(ctx, userID, room.Other(userID))
+		if err != nil {
+			span.RecordError(err)
+			return err
+		}
+		if len(shared) == 0 {
+			return errRoomForbidden
+		}
+		return nil
+
+	case rooms.Conversation:
+		var count int64
+		if err := config.DB.WithContext(ctx).Model(&models.ConversationParticipant{}).
+			Where("conversation_id = ? AND user_id = ?", room.ConversationID, userID).
+			Count(&count).Error; err != nil {
+			span.RecordError(err)
+			return err
+		}
+		if count == 0 {
+			return errRoomForbidden
+		}
+		return nil
+	}
+
+	return errRoomForbidden
+}
+
+// authorizeFrame resolves the room of a room-scoped frame and checks access
+// to it. On success msg.Room holds the room key. It reports
+// false after sending an error frame to the client.
+func (c *Client) authorizeFrame(msg *ClientMessage) bool {
+	var perm permissions.Permission
+	switch msg.Type {
+	case protocol.TypeJoin, protocol.TypeHistory, protocol.TypeRead, protocol.TypeReact, protocol.TypeUnreact:
+		perm = permissions.ChatRead
+	case protocol.TypeBroadcast, protocol.TypeReply, protocol.TypeEdit, protocol.TypeTypingStart,
+		protocol.TypePoll, protocol.TypeVote, protocol.TypeClosePoll:
+		perm = permissions.ChatPost
+	case protocol.TypeDelete:
+		// Authors may always delete their own messages; moderation rights
+		// are checked when the message is looked up.
+		perm = permissions.ChatRead
+	case protocol.TypePin, protocol.TypeUnpin:
+		perm = permissions.ChatPin
+	case protocol.TypeAnnounce:
+		perm = permissions.ChatAnnounce
+	case protocol.TypeLeave, protocol.TypeTypingStop:
+	default:
+		return true
+	}
+
+	userID, _ := uuid.Parse(c.UserID)
+	room, err := rooms.Parse(msg.Room, userID)
+	if err != nil {
+		c.sendError(msg.ID, protocol.CodeInvalidRoom, msg.Room, "Unknown room")
+		return false
+	}
+
+	if perm != "" {
+		err := authorizeRoom(context.Background(), userID, room, perm)
+		if errors.Is(err, errRoomForbidden) {
+			c.log.Warn("chat access denied",
+				zap.String("room_id", room.String()),
+				zap.String("frame", msg.Type),
+			)
+			c.sendError(msg.ID, protocol.CodeForbidden, room.String(), "You do not have access to this room")
+			return false
+		}
+		if err != nil {
+			c.log.Error("chat access check failed", zap.String("room_id", room.String()), zap.Error(err))
+			c.sendError(msg.ID, protocol.CodeForbidden, room.String(), "Could not verify access to this room")
+			return false
+		}
+	}
+
+	msg.Room = room.Key()
+	return true
+}
+
+var membershipRevokedHooks []func(groupID, userID uuid.UUID)
+
+// OnMembershipRevoked registers fn to run when a user stops being a joined
+// member of a group.
+func OnMembershipRevoked(fn func(groupID, userID uuid.UUID)) {
+	membershipRevokedHooks = append(membershipRevokedHooks, fn)
+}
+
+func membershipRevoked(groupID, userID uuid.UUID) {
+	for _, fn := range membershipRevokedHooks {
+		fn(groupID, userID)
+	}
+}
+
+type roomRevocation struct {
+	roomKey string
+	userID  string
+}
+
+// RevokeGroupAccess removes the user's connections from the group's room
+// on every instance.
+func (s *Server) RevokeGroupAccess(groupID, userID uuid.UUID) {
+	roomKey := rooms.Room{Kind: rooms.Group, GroupID: groupID}.Key()
+	if err := s.fanout.Publish(context.Background(), fanout.Event{Room: roomKey, RevokeUser: userID.String()}); err != nil {
+		s.log.Error("failed to publish access revocation", zap.String("room_id", roomKey), zap.Error(err))
+	}
+}
+
+func (s *Server) applyRevocation(r roomRevocation) {
+	for client := range s.clients {
+		if client.UserID != r.userID || !client.rooms[r.roomKey] {
+			continue
+		}
+		if hub, ok := s.getHub(r.roomKey); ok {
+			hub.unregister <- client
+		}
+		delete(client.rooms, r.roomKey)
+		s.leavePresence(client, r.roomKey)
+
+		room, _ := rooms.FromKey(r.roomKey)
+		client.sendError("", protocol.CodeAccessRevoked, room.String(), "You are no longer a member of this group")
+		client.log.Info("removed client from room after membership change", zap.String("room_id", r.roomKey))
+	}
+}
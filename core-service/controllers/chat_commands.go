@@ -0,0 +1,194 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"core-service/internal/chat/commands"
+	"core-service/internal/chat/protocol"
+	"core-service/internal/chat/rooms"
+	"core-service/internal/permissions"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.uber.org/zap"
+)
+
+// maxListedTasks bounds the reply to /tasks.
+const maxListedTasks = 10
+
+// RegisterCommand makes a slash command available in chat. Commands run on
+// the server's event loop, so handlers should return quickly.
+func (s *Server) RegisterCommand(cmd commands.Command) error {
+	return s.commands.Register(cmd)
+}
+
+func (s *Server) registerBuiltinCommands() {
+	for _, cmd := range []commands.Command{
+		{
+			Name:        "help",
+			Usage:       "/help",
+			Description: "List the available commands",
+			Handler:     s.helpCommand,
+		},
+		{
+			Name:        "task",
+			Usage:       `/task "title" due:<date> [description:"..."]`,
+			Description: "Add a task to this group",
+			Permission:  permissions.TaskCreate,
+			Handler:     taskCommand,
+		},
+		{
+			Name:        "poll",
+			Usage:       `/poll "question" "option" "option"... [multi:yes] [anonymous:yes] [closes:<date>]`,
+			Description: "Ask the group to vote",
+			Permission:  permissions.ChatPost,
+			Handler:     s.pollCommand,
+		},
+		{
+			Name:        "tasks",
+			Usage:       "/tasks",
+			Description: "List this group's upcoming tasks",
+			Permission:  permissions.TaskView,
+			Handler:     tasksCommand,
+		},
+	} {
+		if err := s.RegisterCommand(cmd); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// runCommand runs the slash command in a broadcast frame instead of saving
+// it as a message.
+func (s *Server) runCommand(client *Client, msg *ClientMessage, room rooms.Room) {
+	ctx, span := chatTracer.Start(context.Background(), "chat.command")
+	defer span.End()
+	span.SetAttributes(
+		attribute.String("room.id", msg.Room),
+		attribute.String("user.id", client.UserID),
+	)
+
+	inv, err := commands.Parse(msg.Text)
+	if err != nil {
+		client.sendError(msg.ID, protocol.CodeInvalidCommand, room.String(), err.Error())
+		return
+	}
+	span.SetAttributes(attribute.String("command.name", inv.Name))
+
+	cmd, ok := s.commands.Lookup(inv.Name)
+	if !ok {
+		span.AddEvent("unknown_command")
+		client.sendError(msg.ID, protocol.CodeUnknownCommand, room.String(), fmt.Sprintf("Unknown command /%s; try /help", inv.Name))
+		return
+	}
+
+	userID := uuid.MustParse(client.UserID)
+	if cmd.Permission != "" {
+		if room.Kind != rooms.Group {
+			client.sendError(msg.ID, protocol.CodeInvalidCommand, room.String(), fmt.Sprintf("/%s only works in group chats", cmd.Name))
+			return
+		}
+		if err := authorizeRoom(ctx, userID, room, cmd.Permission); err != nil {
+			if errors.Is(err, errRoomForbidden) {
+				span.AddEvent("command_forbidden")
+				client.sendError(msg.ID, protocol.CodeForbidden, room.String(), fmt.Sprintf("You are not allowed to use /%s here", cmd.Name))
+				return
+			}
+			span.RecordError(err)
+			client.log.Error("failed to authorize command", zap.String("command", cmd.Name), zap.Error(err))
+			client.sendError(msg.ID, protocol.CodeInternal, room.String(), "Could not run command")
+			return
+		}
+	}
+
+	inv.UserID = userID
+	inv.Username = client.Username
+	inv.Room = room
+
+	reply, err := cmd.Handler(ctx, inv)
+	if err != nil {
+		var usage *commands.UsageError
+		if errors.As(err, &usage) {
+			client.sendError(msg.ID, protocol.CodeInvalidCommand, room.String(), usage.Message+" (usage: "+cmd.Usage+")")
+			return
+		}
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "command failed")
+		client.log.Error("command failed", zap.String("command", cmd.Name), zap.Error(err))
+		client.sendError(msg.ID, protocol.CodeInternal, room.String(), fmt.Sprintf("/%s failed", cmd.Name))
+		return
+	}
+
+	s.stopTyping(client, msg.Room)
+	if reply.Text != "" {
+		notice := protocol.Notice{
+			Room:      room.String(),
+			Command:   cmd.Name,
+			User:      protocol.Author{ID: client.UserID, Name: client.Username},
+			Text:      reply.Text,
+			Ephemeral: !reply.Broadcast,
+		}
+		if reply.Broadcast {
+			s.broadcastEvent(msg.Room, protocol.TypeNotice, notice)
+		} else {
+			client.sendFrame(protocol.TypeNotice, "", notice)
+		}
+	}
+	span.SetStatus(codes.Ok, "command run")
+	client.ack(msg.ID, protocol.Ack{Room: room.String()})
+}
+
+func (s *Server) helpCommand(ctx context.Context, inv commands.Invocation) (commands.Reply, error) {
+	var b strings.Builder
+	b.WriteString("Commands:")
+	for _, cmd := range s.commands.List() {
+		fmt.Fprintf(&b, "\n%s — %s", cmd.Usage, cmd.Description)
+	}
+	return commands.Reply{Text: b.String()}, nil
+}
+
+func taskCommand(ctx context.Context, inv commands.Invocation) (commands.Reply, error) {
+	title := strings.TrimSpace(strings.Join(inv.Args, " "))
+	if title == "" {
+		return commands.Reply{}, commands.Usagef("give the task a title")
+	}
+	due, ok := inv.Options["due"]
+	if !ok {
+		return commands.Reply{}, commands.Usagef("add a due date, e.g. due:fri")
+	}
+	deadline, err := commands.ParseDate(due, time.Now())
+	if err != nil {
+		return commands.Reply{}, err
+	}
+
+	task, err := createTask(ctx, inv.Room.GroupID, inv.UserID, title, inv.Options["description"], "pending", deadline)
+	if err != nil {
+		return commands.Reply{}, err
+	}
+	return commands.Reply{
+		Text:      fmt.Sprintf("%s added a task: %s (due %s)", inv.Username, task.Title, task.Deadline.Format("Mon Jan 2 15:04")),
+		Broadcast: true,
+	}, nil
+}
+
+func tasksCommand(ctx context.Context, inv commands.Invocation) (commands.Reply, error) {
+	tasks, err := upcomingTasks(ctx, inv.Room.GroupID, maxListedTasks)
+	if err != nil {
+		return commands.Reply{}, err
+	}
+	if len(tasks) == 0 {
+		return commands.Reply{Text: "No upcoming tasks"}, nil
+	}
+
+	var b strings.Builder
+	b.WriteString("Upcoming tasks:")
+	for _, task := range tasks {
+		fmt.Fprintf(&b, "\n• %s — due %s", task.Title, task.Deadline.Format("Mon Jan 2 15:04"))
+	}
+	return commands.Reply{Text: b.String()}, nil
+}
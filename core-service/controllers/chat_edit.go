@@ -0,0 +1,237 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"core-service/config"
+	"core-service/internal/chat/protocol"
+	"core-service/internal/chat/rooms"
+	"core-service/internal/observability/logging"
+	"core-service/internal/permissions"
+	"core-service/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	errMessageNotEditable = errors.New("message cannot be changed")
+	errMessageUnchanged   = errors.New("message text unchanged")
+)
+
+// editMessage replaces the text of the client's own message and records
+// the previous version.
+func (s *Server) editMessage(client *Client, req *ClientMessage) {
+	roomKey, text := req.Room, req.Text
+
+	ctx, span := chatTracer.Start(context.Background(), "chat.message.edit")
+	defer span.End()
+
+	room, _ := rooms.FromKey(roomKey)
+	span.SetAttributes(
+		attribute.String("room.id", roomKey),
+		attribute.String("user.id", client.UserID),
+		attribute.String("message.id", req.MessageID),
+	)
+
+	messageID, err := uuid.Parse(req.MessageID)
+	if err != nil {
+		client.sendError(req.ID, protocol.CodeNotFound, room.String(), "Message not found")
+		return
+	}
+	text = strings.TrimSpace(text)
+	if text == "" {
+		client.sendError(req.ID, protocol.CodeInvalidFrame, room.String(), "Message text cannot be empty")
+		return
+	}
+	userID, _ := uuid.Parse(client.UserID)
+
+	var msg models.ChatMessage
+	err = config.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			First(&msg, "id = ? AND room_id = ?", messageID, roomKey).Error; err != nil {
+			return err
+		}
+		if msg.UserID != userID || msg.DeletedAt != nil || msg.Kind == models.MessagePoll {
+			// A poll's question cannot change once people may have voted.
+			return errMessageNotEditable
+		}
+		if msg.Text == text {
+			return errMessageUnchanged
+		}
+
+		now := time.Now()
+		if err := tx.Create(&models.ChatMessageEdit{
+			ChatMessageID: msg.ID,
+			EditorID:      userID,
+			PreviousText:  msg.Text,
+			EditedAt:      now,
+		}).Error; err != nil {
+			return err
+		}
+		return tx.Model(&msg).Updates(map[string]interface{}{"text": text, "edited_at": now}).Error
+	})
+	if errors.Is(err, errMessageUnchanged) {
+		client.ack(req.ID, protocol.Ack{Room: room.String(), MessageID: msg.ID.String()})
+		return
+	}
+	if !s.reportMessageChangeError(client, req.ID, room, err, "edit") {
+		span.SetStatus(codes.Error, "edit rejected")
+		return
+	}
+
+	if err := config.DB.WithContext(ctx).Preload("User").Preload("Attachments").
+		First(&msg, "id = ?", msg.ID).Error; err != nil {
+		span.RecordError(err)
+		client.log.Error("failed to reload edited message", zap.Error(err))
+		client.sendError(req.ID, protocol.CodeInternal, room.String(), "Message was edited but could not be reloaded")
+		return
+	}
+
+	s.recordMentions(ctx, &msg)
+
+	span.SetStatus(codes.Ok, "message edited")
+	s.broadcastEvent(roomKey, protocol.TypeMessageUpdated, protocol.MessageUpdated{
+		Room:    room.String(),
+		Message: s.toServerMessages(ctx, []models.ChatMessage{msg})[0],
+	})
+	client.ack(req.ID, protocol.Ack{Room: room.String(), MessageID: msg.ID.String()})
+}
+
+// deleteMessage soft-deletes a message. Authors may delete their own
+// messages; members with chat.moderate may delete anyone's in their group.
+func (s *Server) deleteMessage(client *Client, req *ClientMessage) {
+	roomKey := req.Room
+
+	ctx, span := chatTracer.Start(context.Background(), "chat.message.delete")
+	defer span.End()
+
+	room, _ := rooms.FromKey(roomKey)
+	span.SetAttributes(
+		attribute.String("room.id", roomKey),
+		attribute.String("user.id", client.UserID),
+		attribute.String("message.id", req.MessageID),
+	)
+
+	messageID, err := uuid.Parse(req.MessageID)
+	if err != nil {
+		client.sendError(req.ID, protocol.CodeNotFound, room.String(), "Message not found")
+		return
+	}
+	userID, _ := uuid.Parse(client.UserID)
+
+	var msg models.ChatMessage
+	if err := config.DB.WithContext(ctx).
+		First(&msg, "id = ? AND room_id = ?", messageID, roomKey).Error; err != nil {
+		s.reportMessageChangeError(client, req.ID, room, err, "delete")
+		return
+	}
+
+	if msg.UserID != userID {
+		if room.Kind != rooms.Group || authorizeRoom(ctx, userID, room, permissions.ChatModerate) != nil {
+			s.reportMessageChangeError(client, req.ID, room, errMessageNotEditable, "delete")
+			return
+		}
+		span.AddEvent("moderator_delete")
+	}
+
+	now := time.Now()
+	result := config.DB.WithContext(ctx).Model(&models.ChatMessage{}).
+		Where("id = ? AND deleted_at IS NULL", msg.ID).
+		Updates(map[string]interface{}{"deleted_at": now, "deleted_by": userID, "pinned_at": nil, "pinned_by": nil})
+	if !s.reportMessageChangeError(client, req.ID, room, result.Error, "delete") {
+		return
+	}
+	if result.RowsAffected == 0 {
+		// Already deleted; nothing new to tell the room.
+		client.ack(req.ID, protocol.Ack{Room: room.String(), MessageID: msg.ID.String()})
+		return
+	}
+
+	client.log.Info("chat message deleted",
+		zap.String("message_id", msg.ID.String()),
+		zap.String("author_id", msg.UserID.String()),
+	)
+	span.SetStatus(codes.Ok, "message deleted")
+	s.broadcastEvent(roomKey, protocol.TypeMessageDeleted, protocol.MessageDeleted{
+		Room:      room.String(),
+		MessageID: msg.ID.String(),
+		DeletedAt: now,
+	})
+	if msg.ParentID != nil {
+		s.broadcastThreadUpdate(ctx, roomKey, *msg.ParentID)
+	}
+	client.ack(req.ID, protocol.Ack{Room: room.String(), MessageID: msg.ID.String()})
+}
+
+// reportMessageChangeError sends the matching error frame for err and
+// reports whether err was nil.
+func (s *Server) reportMessageChangeError(client *Client, id string, room rooms.Room, err error, action string) bool {
+	switch {
+	case err == nil:
+		return true
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		client.sendError(id, protocol.CodeNotFound, room.String(), "Message not found")
+	case errors.Is(err, errMessageNotEditable):
+		client.sendError(id, protocol.CodeForbidden, room.String(), "You cannot "+action+" this message")
+	default:
+		client.log.Error("failed to "+action+" message", zap.String("room_id", room.Key()), zap.Error(err))
+		client.sendError(id, protocol.CodeInternal, room.String(), "Could not "+action+" message")
+	}
+	return false
+}
+
+// ListMessageEdits returns the earlier versions of a message, newest first.
+func (h *ChatHandler) ListMessageEdits(c *gin.Context) {
+	ctx := c.Request.Context()
+	log := logging.Logger(ctx)
+
+	ctx, span := chatTracer.Start(ctx, "chat.message.edits")
+	defer span.End()
+
+	groupID, errGroup := uuid.Parse(c.Param("groupId"))
+	messageID, errMsg := uuid.Parse(c.Param("messageId"))
+	if errGroup != nil || errMsg != nil {
+		span.AddEvent("invalid_id")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	room := rooms.Room{Kind: rooms.Group, GroupID: groupID}
+	span.SetAttributes(
+		attribute.String("room.id", room.String()),
+		attribute.String("message.id", messageID.String()),
+	)
+
+	var msg models.ChatMessage
+	if err := config.DB.WithContext(ctx).
+		First(&msg, "id = ? AND room_id = ? AND deleted_at IS NULL", messageID, room.Key()).Error; err != nil {
+		span.AddEvent("message_not_found")
+		c.JSON(http.StatusNotFound, gin.H{"error": "Message not found"})
+		return
+	}
+
+	var edits []models.ChatMessageEdit
+	if err := config.DB.WithContext(ctx).
+		Where("chat_message_id = ?", msg.ID).
+		Order("edited_at desc").
+		Find(&edits).Error; err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "edit history query failed")
+		log.Error("failed to fetch edit history", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch edit history"})
+		return
+	}
+
+	span.SetStatus(codes.Ok, "edit history listed")
+	c.JSON(http.StatusOK, gin.H{"message_id": msg.ID, "edits": edits})
+}
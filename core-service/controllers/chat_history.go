@@ -0,0 +1,214 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"core-service/config"
+	"core-service/internal/chat/protocol"
+	"core-service/internal/chat/rooms"
+	"core-service/internal/observability/logging"
+	"core-service/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const (
+	historyDefaultLimit = 50
+	historyMaxLimit     = 100
+)
+
+var errUnknownCursor = errors.New("unknown history cursor")
+
+// loadHistory returns up to limit top-level messages of the room older than
+// the message before (or the newest ones when before is nil), oldest first.
+// Thread replies are left out; see loadReplies.
+// Pages are keyed on (timestamp, id) so they stay stable while new messages
+// arrive. The returned cursor is nil once the start of the room is reached.
+func loadHistory(ctx context.Context, roomKey string, before *uuid.UUID, limit int) ([]models.ChatMessage, *uuid.UUID, error) {
+	ctx, span := chatTracer.Start(ctx, "chat.history.page")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("room.id", roomKey),
+		attribute.Int("limit", limit),
+	)
+
+	q := config.DB.WithContext(ctx).Preload("User").
+		Preload("Attachments").
+		Where("room_id = ? AND parent_id IS NULL", roomKey)
+
+	if before != nil {
+		var cursor models.ChatMessage
+		err := config.DB.WithContext(ctx).Select("id", "timestamp").
+			First(&cursor, "id = ? AND room_id = ?", *before, roomKey).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, errUnknownCursor
+		}
+		if err != nil {
+			span.RecordError(err)
+			return nil, nil, err
+		}
+		q = q.Where("(timestamp, id) < (?, ?)", cursor.Timestamp, cursor.ID)
+	}
+
+	var messages []models.ChatMessage
+	if err := q.Order("timestamp desc, id desc").
+		Limit(limit + 1).
+		Find(&messages).Error; err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "db query failed")
+		return nil, nil, err
+	}
+
+	var next *uuid.UUID
+	if len(messages) > limit {
+		messages = messages[:limit]
+		oldest := messages[limit-1].ID
+		next = &oldest
+	}
+
+	for i, j := 0, len(messages)-1; i < j; i, j = i+1, j-1 {
+		messages[i], messages[j] = messages[j], messages[i]
+	}
+
+	span.SetAttributes(attribute.Int("messages.count", len(messages)))
+	return messages, next, nil
+}
+
+// parseHistoryParams reads the cursor and page size shared by the history
+// and thread endpoints and the history frame.
+func parseHistoryParams(cursorRaw, limitRaw string) (*uuid.UUID, int, error) {
+	limit := historyDefaultLimit
+	if limitRaw != "" {
+		n, err := strconv.Atoi(limitRaw)
+		if err != nil || n < 1 {
+			return nil, 0, errors.New("limit must be a positive number")
+		}
+		if n > historyMaxLimit {
+			n = historyMaxLimit
+		}
+		limit = n
+	}
+
+	if cursorRaw == "" {
+		return nil, limit, nil
+	}
+	cursor, err := uuid.Parse(cursorRaw)
+	if err != nil {
+		return nil, 0, errUnknownCursor
+	}
+	return &cursor, limit, nil
+}
+
+func cursorString(id *uuid.UUID) *string {
+	if id == nil {
+		return nil
+	}
+	s := id.String()
+	return &s
+}
+
+// ListMessages pages backwards through a group's chat history.
+func (h *ChatHandler) ListMessages(c *gin.Context) {
+	ctx := c.Request.Context()
+	log := logging.Logger(ctx)
+
+	ctx, span := chatTracer.Start(ctx, "chat.history.list")
+	defer span.End()
+
+	groupID, err := uuid.Parse(c.Param("groupId"))
+	if err != nil {
+		span.AddEvent("invalid_group_id")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid group ID"})
+		return
+	}
+
+	room := rooms.Room{Kind: rooms.Group, GroupID: groupID}
+	span.SetAttributes(attribute.String("room.id", room.String()))
+
+	before, limit, err := parseHistoryParams(c.Query("before"), c.Query("limit"))
+	if err != nil {
+		span.AddEvent("invalid_params")
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	messages, next, err := loadHistory(ctx, room.Key(), before, limit)
+	if errors.Is(err, errUnknownCursor) {
+		span.AddEvent("unknown_cursor")
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "history query failed")
+		log.Error("failed to fetch chat history", zap.String("room_id", room.Key()), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch messages"})
+		return
+	}
+
+	span.SetStatus(codes.Ok, "history listed")
+	c.JSON(http.StatusOK, gin.H{
+		"room":        room.String(),
+		"messages":    h.server.toServerMessages(ctx, messages),
+		"next_cursor": cursorString(next),
+	})
+}
+
+// sendHistoryPage answers a "history" frame, and sends the newest page to
+// clients that just joined a room.
+func (s *Server) sendHistoryPage(client *Client, id, roomKey, beforeRaw string, limitRaw int) {
+	ctx, span := chatTracer.Start(context.Background(), "chat.history.fetch")
+	defer span.End()
+
+	room, _ := rooms.FromKey(roomKey)
+	span.SetAttributes(
+		attribute.String("room.id", roomKey),
+		attribute.String("user.id", client.UserID),
+	)
+
+	limitStr := ""
+	if limitRaw != 0 {
+		limitStr = strconv.Itoa(limitRaw)
+	}
+
+	before, limit, err := parseHistoryParams(beforeRaw, limitStr)
+	if errors.Is(err, errUnknownCursor) {
+		client.sendError(id, protocol.CodeInvalidCursor, room.String(), err.Error())
+		return
+	}
+	if err != nil {
+		client.sendError(id, protocol.CodeInvalidFrame, room.String(), err.Error())
+		return
+	}
+
+	messages, next, err := loadHistory(ctx, roomKey, before, limit)
+	if errors.Is(err, errUnknownCursor) {
+		client.sendError(id, protocol.CodeInvalidCursor, room.String(), err.Error())
+		return
+	}
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "history query failed")
+		client.log.Error("failed to load history page", zap.String("room_id", roomKey), zap.Error(err))
+		client.sendError(id, protocol.CodeInternal, room.String(), "Could not load history")
+		return
+	}
+
+	page := protocol.HistoryPage{
+		Room:       room.String(),
+		Messages:   s.toServerMessages(ctx, messages),
+		NextCursor: cursorString(next),
+	}
+
+	span.SetAttributes(attribute.Int("messages.count", len(messages)))
+	client.sendFrame(protocol.TypeHistory, id, page)
+}
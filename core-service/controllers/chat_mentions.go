@@ -0,0 +1,227 @@
+package controllers
+
+import (
+	"context"
+	"strings"
+
+	"core-service/config"
+	"core-service/internal/chat/mentions"
+	"core-service/internal/chat/rooms"
+	"core-service/internal/permissions"
+	"core-service/models"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Mention kinds stored on ChatMention.
+const (
+	mentionUser   = "user"
+	mentionHere   = "here"
+	mentionAdmins = "admins"
+)
+
+type roomMember struct {
+	UserID   uuid.UUID
+	Username string
+	Role     string
+}
+
+// roomMembers returns everyone who can read the room.
+func roomMembers(ctx context.Context, room rooms.Room) ([]roomMember, error) {
+	var members []roomMember
+	q := config.DB.WithContext(ctx).Table("users")
+
+	switch room.Kind {
+	case rooms.Group:
+		q = q.Select("users.id AS user_id, users.username, group_members.role").
+			Joins("JOIN group_members ON group_members.user_id = users.id").
+			Where("group_members.group_id = ? AND group_members.status = ?", room.GroupID, "joined")
+	case rooms.Direct:
+		q = q.Select("users.id AS user_id, users.username").
+			Where("users.id IN ?", room.Users[:])
+	case rooms.Conversation:
+		q = q.Select("users.id AS user_id, users.username").
+			Joins("JOIN conversation_participants cp ON cp.user_id = users.id").
+			Where("cp.conversation_id = ?", room.ConversationID)
+	}
+
+	err := q.Scan(&members).Error
+	return members, err
+}
+
+// recordMentions resolves the mentions in a saved or edited message against
+// the room's members and stores them, dropping any an edit removed. Each
+// newly mentioned user is left a notification; unread notifications for
+// removed mentions are withdrawn. The message is already saved, so failures
+// are only logged.
+func (s *Server) recordMentions(ctx context.Context, msg *models.ChatMessage) {
+	edited := msg.EditedAt != nil
+	set := mentions.Parse(msg.Text)
+	if set.Empty() && !edited {
+		return
+	}
+
+	ctx, span := chatTracer.Start(ctx, "chat.message.mentions")
+	defer span.End()
+	span.SetAttributes(
+		attribute.String("room.id", msg.RoomID),
+		attribute.String("message.id", msg.ID.String()),
+		attribute.Bool("message.edited", edited),
+	)
+
+	room, err := rooms.FromKey(msg.RoomID)
+	if err != nil {
+		return
+	}
+	mentioned, err := s.resolveMentions(ctx, msg, room, set)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "member lookup failed")
+		s.log.Error("failed to resolve mentions", zap.String("room_id", msg.RoomID), zap.Error(err))
+		return
+	}
+	span.SetAttributes(attribute.Int("mentions.count", len(mentioned)))
+
+	db := config.DB.WithContext(ctx)
+
+	// Only users who were not mentioned before the edit are notified.
+	previous := map[uuid.UUID]bool{}
+	if edited {
+		var before []uuid.UUID
+		if err := db.Model(&models.ChatMention{}).Where("message_id = ?", msg.ID).
+			Pluck("user_id", &before).Error; err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "mention lookup failed")
+			s.log.Error("failed to load mentions", zap.String("message_id", msg.ID.String()), zap.Error(err))
+			return
+		}
+		for _, id := range before {
+			previous[id] = true
+		}
+
+		kept := make([]uuid.UUID, 0, len(mentioned))
+		for _, m := range mentioned {
+			kept = append(kept, m.UserID)
+		}
+		if err := removeStaleMentions(db, msg.ID, kept); err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "mention cleanup failed")
+			s.log.Error("failed to remove stale mentions", zap.String("message_id", msg.ID.String()), zap.Error(err))
+			return
+		}
+	}
+	if len(mentioned) == 0 {
+		return
+	}
+
+	notifiedAt := msg.Timestamp
+	if edited {
+		notifiedAt = *msg.EditedAt
+	}
+	body := msg.User.Username + " mentioned you: " + previewText(msg.Text)
+	notifications := make([]models.Notification, 0, len(mentioned))
+	for _, m := range mentioned {
+		if previous[m.UserID] {
+			continue
+		}
+		notifications = append(notifications, models.Notification{
+			UserID:    m.UserID,
+			Kind:      models.NotificationMention,
+			Room:      room.String(),
+			MessageID: &msg.ID,
+			ActorID:   &msg.UserID,
+			Body:      body,
+			CreatedAt: notifiedAt,
+		})
+	}
+
+	if err := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&mentioned).Error; err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "mention insert failed")
+		s.log.Error("failed to store mentions", zap.String("message_id", msg.ID.String()), zap.Error(err))
+		return
+	}
+	if len(notifications) == 0 {
+		return
+	}
+	if err := db.Create(&notifications).Error; err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "notification insert failed")
+		s.log.Error("failed to store mention notifications", zap.String("message_id", msg.ID.String()), zap.Error(err))
+	}
+}
+
+// resolveMentions returns the room members that set mentions in msg. The
+// author is never mentioned.
+func (s *Server) resolveMentions(ctx context.Context, msg *models.ChatMessage, room rooms.Room, set mentions.Set) ([]models.ChatMention, error) {
+	if set.Empty() {
+		return nil, nil
+	}
+	members, err := roomMembers(ctx, room)
+	if err != nil {
+		return nil, err
+	}
+
+	online := map[string]bool{}
+	if set.Here {
+		present, err := s.fanout.Online(ctx, msg.RoomID)
+		if err != nil {
+			s.log.Warn("failed to read presence for @here", zap.String("room_id", msg.RoomID), zap.Error(err))
+		}
+		for _, p := range present {
+			online[p.UserID] = true
+		}
+	}
+	named := map[string]bool{}
+	for _, name := range set.Usernames {
+		named[name] = true
+	}
+
+	var mentioned []models.ChatMention
+	for _, m := range members {
+		if m.UserID == msg.UserID {
+			continue
+		}
+		kind := ""
+		switch {
+		case named[strings.ToLower(m.Username)]:
+			kind = mentionUser
+		case set.Admins && room.Kind == rooms.Group && permissions.Has(m.Role, permissions.MemberManage):
+			kind = mentionAdmins
+		case set.Here && online[m.UserID.String()]:
+			kind = mentionHere
+		default:
+			continue
+		}
+		mentioned = append(mentioned, models.ChatMention{
+			MessageID: msg.ID,
+			UserID:    m.UserID,
+			RoomID:    msg.RoomID,
+			Kind:      kind,
+			CreatedAt: msg.Timestamp,
+		})
+	}
+	return mentioned, nil
+}
+
+// removeStaleMentions deletes the message's mentions of users not in kept,
+// along with their unread mention notifications.
+func removeStaleMentions(db *gorm.DB, messageID uuid.UUID, kept []uuid.UUID) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		mentionsQ := tx.Where("message_id = ?", messageID)
+		notificationsQ := tx.Where("message_id = ? AND kind = ? AND read_at IS NULL", messageID, models.NotificationMention)
+		if len(kept) > 0 {
+			mentionsQ = mentionsQ.Where("user_id NOT IN ?", kept)
+			notificationsQ = notificationsQ.Where("user_id NOT IN ?", kept)
+		}
+		if err := mentionsQ.Delete(&models.ChatMention{}).Error; err != nil {
+			return err
+		}
+		return notificationsQ.Delete(&models.Notification{}).Error
+	})
+}
@@ -0,0 +1,182 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"core-service/config"
+	"core-service/internal/chat/protocol"
+	"core-service/internal/chat/rooms"
+	"core-service/internal/observability/logging"
+	"core-service/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.uber.org/zap"
+)
+
+// pin pins (or, for unpin, unpins) a message in the client's group room
+// and tells the room. Repeating either is acked without an event.
+func (s *Server) pin(client *Client, req *ClientMessage) {
+	pinned := req.Type == protocol.TypePin
+
+	ctx, span := chatTracer.Start(context.Background(), "chat.message."+req.Type)
+	defer span.End()
+
+	room, _ := rooms.FromKey(req.Room)
+	span.SetAttributes(
+		attribute.String("room.id", req.Room),
+		attribute.String("user.id", client.UserID),
+		attribute.String("message.id", req.MessageID),
+	)
+
+	messageID, err := uuid.Parse(req.MessageID)
+	if err != nil {
+		client.sendError(req.ID, protocol.CodeNotFound, room.String(), "Message not found")
+		return
+	}
+	userID, _ := uuid.Parse(client.UserID)
+
+	var msg models.ChatMessage
+	if err := config.DB.WithContext(ctx).
+		First(&msg, "id = ? AND room_id = ? AND deleted_at IS NULL", messageID, req.Room).Error; err != nil {
+		s.reportMessageChangeError(client, req.ID, room, err, req.Type)
+		return
+	}
+
+	updates := map[string]interface{}{"pinned_at": nil, "pinned_by": nil}
+	q := config.DB.WithContext(ctx).Model(&models.ChatMessage{}).Where("id = ?", msg.ID)
+	if pinned {
+		updates = map[string]interface{}{"pinned_at": time.Now(), "pinned_by": userID}
+		q = q.Where("pinned_at IS NULL")
+	} else {
+		q = q.Where("pinned_at IS NOT NULL")
+	}
+	result := q.Updates(updates)
+	if !s.reportMessageChangeError(client, req.ID, room, result.Error, req.Type) {
+		span.RecordError(result.Error)
+		span.SetStatus(codes.Error, "pin update failed")
+		return
+	}
+
+	if result.RowsAffected > 0 {
+		event := protocol.PinUpdated{
+			Room:      room.String(),
+			MessageID: msg.ID.String(),
+			UserID:    client.UserID,
+			Pinned:    pinned,
+		}
+		if pinned {
+			if err := config.DB.WithContext(ctx).Preload("User").Preload("Attachments").
+				First(&msg, "id = ?", msg.ID).Error; err != nil {
+				client.log.Warn("failed to reload pinned message", zap.Error(err))
+			} else {
+				event.Message = s.toServerMessages(ctx, []models.ChatMessage{msg})[0]
+			}
+		}
+		client.log.Info("chat message pin changed",
+			zap.String("message_id", msg.ID.String()),
+			zap.Bool("pinned", pinned),
+		)
+		s.broadcastEvent(req.Room, protocol.TypePinUpdated, event)
+	}
+	span.SetStatus(codes.Ok, "pin updated")
+	client.ack(req.ID, protocol.Ack{Room: room.String(), MessageID: msg.ID.String()})
+}
+
+// notifyAnnouncement leaves a notification for every member of the
+// announcement's room who is not online to see it. The announcement is
+// already posted, so failures are only logged.
+func (s *Server) notifyAnnouncement(ctx context.Context, msg *models.ChatMessage) {
+	ctx, span := chatTracer.Start(ctx, "chat.announcement.notify")
+	defer span.End()
+	span.SetAttributes(
+		attribute.String("room.id", msg.RoomID),
+		attribute.String("message.id", msg.ID.String()),
+	)
+
+	room, err := rooms.FromKey(msg.RoomID)
+	if err != nil {
+		return
+	}
+	members, err := roomMembers(ctx, room)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "member lookup failed")
+		s.log.Error("failed to list announcement recipients", zap.String("room_id", msg.RoomID), zap.Error(err))
+		return
+	}
+	present, err := s.fanout.Online(ctx, msg.RoomID)
+	if err != nil {
+		// Better to notify someone who saw it than to miss someone who didn't.
+		s.log.Warn("failed to read presence for announcement", zap.String("room_id", msg.RoomID), zap.Error(err))
+	}
+	online := make(map[string]bool, len(present))
+	for _, p := range present {
+		online[p.UserID] = true
+	}
+
+	body := msg.User.Username + " posted an announcement: " + previewText(msg.Text)
+	var notifications []models.Notification
+	for _, m := range members {
+		if m.UserID == msg.UserID || online[m.UserID.String()] {
+			continue
+		}
+		notifications = append(notifications, models.Notification{
+			UserID:    m.UserID,
+			Kind:      models.NotificationAnnouncement,
+			Room:      room.String(),
+			MessageID: &msg.ID,
+			ActorID:   &msg.UserID,
+			Body:      body,
+			CreatedAt: msg.Timestamp,
+		})
+	}
+	span.SetAttributes(attribute.Int("notifications.count", len(notifications)))
+	if len(notifications) == 0 {
+		return
+	}
+	if err := config.DB.WithContext(ctx).Create(&notifications).Error; err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "notification insert failed")
+		s.log.Error("failed to store announcement notifications", zap.String("message_id", msg.ID.String()), zap.Error(err))
+	}
+}
+
+// ListPins returns the group's pinned messages, most recently pinned first.
+func (h *ChatHandler) ListPins(c *gin.Context) {
+	ctx := c.Request.Context()
+	log := logging.Logger(ctx)
+
+	ctx, span := chatTracer.Start(ctx, "chat.pins.list")
+	defer span.End()
+
+	groupID, err := uuid.Parse(c.Param("groupId"))
+	if err != nil {
+		span.AddEvent("invalid_group_id")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid group ID"})
+		return
+	}
+	room := rooms.Room{Kind: rooms.Group, GroupID: groupID}
+	span.SetAttributes(attribute.String("room.id", room.String()))
+
+	var msgs []models.ChatMessage
+	err = config.DB.WithContext(ctx).Preload("User").Preload("Attachments").
+		Where("room_id = ? AND pinned_at IS NOT NULL AND deleted_at IS NULL", room.Key()).
+		Order("pinned_at desc").
+		Find(&msgs).Error
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "pin query failed")
+		log.Error("failed to fetch pinned messages", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch pinned messages"})
+		return
+	}
+
+	span.SetAttributes(attribute.Int("pins.count", len(msgs)))
+	span.SetStatus(codes.Ok, "pins listed")
+	c.JSON(http.StatusOK, gin.H{"room": room.String(), "pins": h.server.toServerMessages(ctx, msgs)})
+}
@@ -0,0 +1,420 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"core-service/config"
+	"core-service/internal/chat/commands"
+	"core-service/internal/chat/polls"
+	"core-service/internal/chat/protocol"
+	"core-service/internal/chat/rooms"
+	"core-service/internal/observability/metrics"
+	"core-service/internal/permissions"
+	"core-service/models"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// pollSweepInterval is how often polls past their closing time are closed
+// and their final results sent to the room.
+const pollSweepInterval = 30 * time.Second
+
+var errPollClosed = errors.New("poll is closed")
+
+// createPoll posts a poll message to the room. spec must already be
+// normalized.
+func (s *Server) createPoll(ctx context.Context, userID uuid.UUID, roomKey string, spec polls.Spec) (*models.ChatMessage, error) {
+	ctx, span := chatTracer.Start(ctx, "chat.poll.create")
+	defer span.End()
+	span.SetAttributes(
+		attribute.String("room.id", roomKey),
+		attribute.String("user.id", userID.String()),
+		attribute.Int("poll.options", len(spec.Options)),
+	)
+
+	now := time.Now()
+	msg := &models.ChatMessage{
+		RoomID:    roomKey,
+		UserID:    userID,
+		Kind:      models.MessagePoll,
+		Text:      spec.Question,
+		Timestamp: now,
+	}
+	err := config.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(msg).Error; err != nil {
+			return err
+		}
+		poll := models.Poll{
+			MessageID: msg.ID,
+			Multiple:  spec.Multiple,
+			Anonymous: spec.Anonymous,
+			ClosesAt:  spec.ClosesAt,
+			CreatedBy: userID,
+			CreatedAt: now,
+		}
+		for i, text := range spec.Options {
+			poll.Options = append(poll.Options, models.PollOption{Position: i, Text: text})
+		}
+		return tx.Create(&poll).Error
+	})
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "db insert failed")
+		return nil, err
+	}
+	metrics.ChatMessagesSent.Add(ctx, 1)
+
+	if err := config.DB.WithContext(ctx).Preload("User").Preload("Attachments").
+		First(msg, "id = ?", msg.ID).Error; err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "db reload failed")
+		return nil, err
+	}
+	span.SetStatus(codes.Ok, "poll created")
+	return msg, nil
+}
+
+// postPoll handles a poll frame from a client that has joined the room.
+func (s *Server) postPoll(client *Client, req *ClientMessage) {
+	room, _ := rooms.FromKey(req.Room)
+
+	spec, err := polls.Spec{
+		Question:  req.Question,
+		Options:   req.Options,
+		Multiple:  req.Multiple,
+		Anonymous: req.Anonymous,
+		ClosesAt:  req.ClosesAt,
+	}.Normalize(time.Now())
+	if err != nil {
+		client.sendError(req.ID, protocol.CodeInvalidFrame, room.String(), err.Error())
+		return
+	}
+
+	ctx := context.Background()
+	msg, err := s.createPoll(ctx, uuid.MustParse(client.UserID), req.Room, spec)
+	if err != nil {
+		client.log.Error("failed to create poll", zap.String("room_id", req.Room), zap.Error(err))
+		client.sendError(req.ID, protocol.CodeInternal, room.String(), "Could not create poll")
+		return
+	}
+
+	s.stopTyping(client, req.Room)
+	s.broadcastEvent(req.Room, protocol.TypeMessage, s.toServerMessages(ctx, []models.ChatMessage{*msg})[0])
+	client.ack(req.ID, protocol.Ack{
+		Room:      room.String(),
+		MessageID: msg.ID.String(),
+		Timestamp: &msg.Timestamp,
+	})
+}
+
+// pollCommand is /poll "question" "option" "option"... [multi:yes]
+// [anonymous:yes] [closes:<date>].
+func (s *Server) pollCommand(ctx context.Context, inv commands.Invocation) (commands.Reply, error) {
+	if len(inv.Args) == 0 {
+		return commands.Reply{}, commands.Usagef("ask a question")
+	}
+	spec := polls.Spec{Question: inv.Args[0], Options: inv.Args[1:]}
+
+	var err error
+	if spec.Multiple, err = commandFlag(inv.Options, "multi"); err != nil {
+		return commands.Reply{}, err
+	}
+	if spec.Anonymous, err = commandFlag(inv.Options, "anonymous"); err != nil {
+		return commands.Reply{}, err
+	}
+	now := time.Now()
+	if raw, ok := inv.Options["closes"]; ok {
+		closes, err := commands.ParseDate(raw, now)
+		if err != nil {
+			return commands.Reply{}, err
+		}
+		spec.ClosesAt = &closes
+	}
+	if spec, err = spec.Normalize(now); err != nil {
+		return commands.Reply{}, commands.Usagef("%s", err.Error())
+	}
+
+	msg, err := s.createPoll(ctx, inv.UserID, inv.Room.Key(), spec)
+	if err != nil {
+		return commands.Reply{}, err
+	}
+	// The poll message itself is the reply.
+	s.broadcastEvent(msg.RoomID, protocol.TypeMessage, s.toServerMessages(ctx, []models.ChatMessage{*msg})[0])
+	return commands.Reply{}, nil
+}
+
+// commandFlag reads a yes/no option, false when absent.
+func commandFlag(options map[string]string, name string) (bool, error) {
+	raw, ok := options[name]
+	if !ok {
+		return false, nil
+	}
+	switch strings.ToLower(raw) {
+	case "yes", "true", "on":
+		return true, nil
+	case "no", "false", "off":
+		return false, nil
+	}
+	return false, commands.Usagef("%s: must be yes or no", name)
+}
+
+// findPoll returns the poll of a message in the room.
+func findPoll(ctx context.Context, roomKey, messageID string) (*models.Poll, error) {
+	id, err := uuid.Parse(messageID)
+	if err != nil {
+		return nil, gorm.ErrRecordNotFound
+	}
+	var poll models.Poll
+	if err := config.DB.WithContext(ctx).Preload("Options").
+		Joins("JOIN chat_messages m ON m.id = polls.message_id").
+		Where("polls.message_id = ? AND m.room_id = ? AND m.deleted_at IS NULL", id, roomKey).
+		First(&poll).Error; err != nil {
+		return nil, err
+	}
+	return &poll, nil
+}
+
+// vote replaces the client's choices in a poll and sends the room the new
+// totals.
+func (s *Server) vote(client *Client, req *ClientMessage) {
+	ctx, span := chatTracer.Start(context.Background(), "chat.poll.vote")
+	defer span.End()
+
+	room, _ := rooms.FromKey(req.Room)
+	span.SetAttributes(
+		attribute.String("room.id", req.Room),
+		attribute.String("user.id", client.UserID),
+		attribute.String("message.id", req.MessageID),
+		attribute.Int("poll.choices", len(req.OptionIDs)),
+	)
+
+	poll, err := findPoll(ctx, req.Room, req.MessageID)
+	if err == nil && polls.Closed(poll.ClosesAt, poll.ClosedAt, time.Now()) {
+		err = errPollClosed
+	}
+	if errors.Is(err, errPollClosed) {
+		client.sendError(req.ID, protocol.CodeForbidden, room.String(), "This poll is closed")
+		return
+	}
+	if !s.reportMessageChangeError(client, req.ID, room, err, "vote in") {
+		return
+	}
+
+	valid := make(map[string]bool, len(poll.Options))
+	for _, opt := range poll.Options {
+		valid[opt.ID.String()] = true
+	}
+	choices, err := polls.Choices(req.OptionIDs, valid, poll.Multiple)
+	if err != nil {
+		client.sendError(req.ID, protocol.CodeInvalidFrame, room.String(), err.Error())
+		return
+	}
+
+	userID := uuid.MustParse(client.UserID)
+	err = config.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Votes on a poll are serialised on its row, so concurrent votes by
+		// one user cannot both replace the old choice and leave two votes on
+		// a single-choice poll.
+		var locked models.Poll
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			First(&locked, "id = ?", poll.ID).Error; err != nil {
+			return err
+		}
+		now := time.Now()
+		if polls.Closed(locked.ClosesAt, locked.ClosedAt, now) {
+			return errPollClosed
+		}
+
+		if err := tx.Where("poll_id = ? AND user_id = ?", poll.ID, userID).
+			Delete(&models.PollVote{}).Error; err != nil {
+			return err
+		}
+		for _, id := range choices {
+			if err := tx.Create(&models.PollVote{
+				PollID:    poll.ID,
+				OptionID:  uuid.MustParse(id),
+				UserID:    userID,
+				CreatedAt: now,
+			}).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if errors.Is(err, errPollClosed) {
+		client.sendError(req.ID, protocol.CodeForbidden, room.String(), "This poll is closed")
+		return
+	}
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "vote failed")
+		client.log.Error("failed to record vote", zap.String("room_id", req.Room), zap.Error(err))
+		client.sendError(req.ID, protocol.CodeInternal, room.String(), "Could not record vote")
+		return
+	}
+
+	span.SetStatus(codes.Ok, "vote recorded")
+	s.broadcastPollUpdate(ctx, req.Room, poll.MessageID)
+	client.ack(req.ID, protocol.Ack{Room: room.String(), MessageID: poll.MessageID.String()})
+}
+
+// closePoll ends voting early. The poll's author may close it, and so may
+// moderators of the group.
+func (s *Server) closePoll(client *Client, req *ClientMessage) {
+	ctx, span := chatTracer.Start(context.Background(), "chat.poll.close")
+	defer span.End()
+
+	room, _ := rooms.FromKey(req.Room)
+	span.SetAttributes(
+		attribute.String("room.id", req.Room),
+		attribute.String("user.id", client.UserID),
+		attribute.String("message.id", req.MessageID),
+	)
+
+	poll, err := findPoll(ctx, req.Room, req.MessageID)
+	if !s.reportMessageChangeError(client, req.ID, room, err, "close") {
+		return
+	}
+
+	userID := uuid.MustParse(client.UserID)
+	if poll.CreatedBy != userID {
+		if room.Kind != rooms.Group || authorizeRoom(ctx, userID, room, permissions.ChatModerate) != nil {
+			s.reportMessageChangeError(client, req.ID, room, errMessageNotEditable, "close")
+			return
+		}
+		span.AddEvent("moderator_close")
+	}
+
+	result := config.DB.WithContext(ctx).Model(&models.Poll{}).
+		Where("id = ? AND closed_at IS NULL", poll.ID).
+		Update("closed_at", time.Now())
+	if !s.reportMessageChangeError(client, req.ID, room, result.Error, "close") {
+		span.RecordError(result.Error)
+		return
+	}
+	if result.RowsAffected > 0 {
+		s.broadcastPollUpdate(ctx, req.Room, poll.MessageID)
+	}
+	span.SetStatus(codes.Ok, "poll closed")
+	client.ack(req.ID, protocol.Ack{Room: room.String(), MessageID: poll.MessageID.String()})
+}
+
+// closeExpiredPolls closes the polls whose closing time has passed and
+// sends their final results. Every instance runs it; the update makes sure
+// each poll is announced once.
+func (s *Server) closeExpiredPolls(now time.Time) {
+	ctx, span := chatTracer.Start(context.Background(), "chat.poll.sweep")
+	defer span.End()
+
+	var closed []struct {
+		MessageID uuid.UUID
+		RoomID    string
+	}
+	if err := config.DB.WithContext(ctx).Raw(
+		`UPDATE polls p SET closed_at = p.closes_at
+		 FROM chat_messages m
+		 WHERE m.id = p.message_id AND p.closed_at IS NULL AND p.closes_at <= ?
+		 RETURNING p.message_id, m.room_id`,
+		now,
+	).Scan(&closed).Error; err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "poll sweep failed")
+		s.log.Error("failed to close expired polls", zap.Error(err))
+		return
+	}
+
+	span.SetAttributes(attribute.Int("polls.closed", len(closed)))
+	for _, p := range closed {
+		s.broadcastPollUpdate(ctx, p.RoomID, p.MessageID)
+	}
+}
+
+// broadcastPollUpdate sends the room a poll's current totals.
+func (s *Server) broadcastPollUpdate(ctx context.Context, roomKey string, messageID uuid.UUID) {
+	loaded, err := loadPolls(ctx, []uuid.UUID{messageID})
+	if err != nil {
+		s.log.Warn("failed to load poll results", zap.String("message_id", messageID.String()), zap.Error(err))
+		return
+	}
+	poll, ok := loaded[messageID.String()]
+	if !ok {
+		return
+	}
+
+	room, _ := rooms.FromKey(roomKey)
+	s.broadcastEvent(roomKey, protocol.TypePollUpdated, protocol.PollUpdated{
+		Room:      room.String(),
+		MessageID: messageID.String(),
+		Poll:      poll,
+	})
+}
+
+// loadPolls returns the polls of the given messages with their totals,
+// keyed by message ID.
+func loadPolls(ctx context.Context, messageIDs []uuid.UUID) (map[string]*protocol.Poll, error) {
+	var rows []models.Poll
+	if err := config.DB.WithContext(ctx).
+		Preload("Options", func(db *gorm.DB) *gorm.DB { return db.Order("position") }).
+		Where("message_id IN ?", messageIDs).
+		Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return map[string]*protocol.Poll{}, nil
+	}
+
+	pollIDs := make([]uuid.UUID, 0, len(rows))
+	for _, p := range rows {
+		pollIDs = append(pollIDs, p.ID)
+	}
+	var votes []models.PollVote
+	if err := config.DB.WithContext(ctx).
+		Where("poll_id IN ?", pollIDs).
+		Order("created_at, user_id").
+		Find(&votes).Error; err != nil {
+		return nil, err
+	}
+	byOption := map[uuid.UUID][]uuid.UUID{}
+	voters := map[uuid.UUID]map[uuid.UUID]bool{}
+	for _, v := range votes {
+		byOption[v.OptionID] = append(byOption[v.OptionID], v.UserID)
+		if voters[v.PollID] == nil {
+			voters[v.PollID] = map[uuid.UUID]bool{}
+		}
+		voters[v.PollID][v.UserID] = true
+	}
+
+	now := time.Now()
+	out := make(map[string]*protocol.Poll, len(rows))
+	for _, p := range rows {
+		poll := &protocol.Poll{
+			ID:        p.ID.String(),
+			Options:   make([]protocol.PollOption, 0, len(p.Options)),
+			Multiple:  p.Multiple,
+			Anonymous: p.Anonymous,
+			ClosesAt:  p.ClosesAt,
+			Closed:    polls.Closed(p.ClosesAt, p.ClosedAt, now),
+			Voters:    len(voters[p.ID]),
+		}
+		for _, opt := range p.Options {
+			users := byOption[opt.ID]
+			option := protocol.PollOption{ID: opt.ID.String(), Text: opt.Text, Votes: len(users)}
+			if !p.Anonymous {
+				for _, u := range users {
+					option.Users = append(option.Users, u.String())
+				}
+			}
+			poll.Options = append(poll.Options, option)
+		}
+		out[p.MessageID.String()] = poll
+	}
+	return out, nil
+}
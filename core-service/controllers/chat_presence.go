@@ -0,0 +1,386 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"core-service/config"
+	"core-service/internal/chat/fanout"
+	"core-service/internal/chat/protocol"
+	"core-service/internal/chat/rooms"
+	"core-service/internal/observability/logging"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.uber.org/zap"
+)
+
+const (
+	// awayAfter is how long a connection may go without sending a frame
+	// before its user shows as away. It is checked on every ping.
+	awayAfter = 5 * time.Minute
+
+	// typingThrottle limits how often a user's typing.start is passed on;
+	// typingTTL ends typing that is not refreshed.
+	typingThrottle = 3 * time.Second
+	typingTTL      = 6 * time.Second
+
+	// presenceQueueSize bounds the presence updates waiting for the
+	// backend. Run blocks once it is full.
+	presenceQueueSize = 256
+)
+
+// statusChange reports that a connection went idle or became active again.
+type statusChange struct {
+	client *Client
+	away   bool
+}
+
+type typingKey struct {
+	roomKey string
+	userID  string
+}
+
+type typingState struct {
+	user     protocol.Author
+	expires  time.Time
+	lastSent time.Time
+}
+
+// touch records client activity and reports the connection active again if
+// it had gone idle.
+func (c *Client) touch() {
+	c.lastActive.Store(time.Now().UnixNano())
+	if c.idle.CompareAndSwap(true, false) {
+		c.server.status <- statusChange{client: c, away: false}
+	}
+}
+
+// checkIdle runs on each ping and reports the connection away once it has
+// been idle for awayAfter.
+func (c *Client) checkIdle(now time.Time) {
+	last := time.Unix(0, c.lastActive.Load())
+	if now.Sub(last) >= awayAfter && c.idle.CompareAndSwap(false, true) {
+		c.server.status <- statusChange{client: c, away: true}
+	}
+}
+
+// presenceUpdate is one change to a user's presence in a room. Updates are
+// applied in order by runPresence, off Run, because every step is a backend
+// call that may be a database round trip.
+type presenceUpdate struct {
+	userID  string
+	roomKey string
+	// change is applied first, if set.
+	change func(ctx context.Context) error
+	// setAway records away as the user's status on this instance, for
+	// users with connections to the room left here.
+	setAway bool
+	away    bool
+	// joined is acked with the room's online count once the update is
+	// applied.
+	joined *joinAck
+}
+
+// joinAck completes a join frame once the joining user's presence is
+// recorded. It is handed back to Run, which owns the client.
+type joinAck struct {
+	client  *Client
+	id      string
+	roomKey string
+	online  int
+}
+
+// statusIn returns the user's status in a room's presence list.
+func statusIn(online []fanout.Presence, userID string) string {
+	for _, p := range online {
+		if p.UserID != userID {
+			continue
+		}
+		if p.Away {
+			return protocol.StatusAway
+		}
+		return protocol.StatusOnline
+	}
+	return protocol.StatusOffline
+}
+
+// runPresence applies presence updates queued by Run.
+func (s *Server) runPresence() {
+	for u := range s.presence {
+		s.applyPresence(u)
+	}
+}
+
+// applyPresence applies u and tells the room if the user's status changed.
+// The status before the change is only read when the change can alter it.
+func (s *Server) applyPresence(u presenceUpdate) {
+	ctx := context.Background()
+
+	before := ""
+	if u.change != nil || u.setAway {
+		online, err := s.fanout.Online(ctx, u.roomKey)
+		if err != nil {
+			s.log.Warn("failed to read presence", zap.String("room_id", u.roomKey), zap.Error(err))
+		} else {
+			before = statusIn(online, u.userID)
+		}
+	}
+
+	if u.change != nil {
+		if err := u.change(ctx); err != nil {
+			s.log.Warn("failed to update presence", zap.String("room_id", u.roomKey), zap.Error(err))
+			u.setAway = false
+		}
+	}
+	if u.setAway {
+		if err := s.fanout.SetAway(ctx, u.roomKey, u.userID, u.away); err != nil {
+			s.log.Warn("failed to update away status", zap.String("room_id", u.roomKey), zap.Error(err))
+		}
+	}
+
+	online, err := s.fanout.Online(ctx, u.roomKey)
+	if err != nil {
+		s.log.Warn("failed to read presence", zap.String("room_id", u.roomKey), zap.Error(err))
+	}
+	if u.joined != nil {
+		u.joined.online = len(online)
+		go func() { s.joined <- *u.joined }()
+	}
+	if err != nil || before == "" {
+		return
+	}
+
+	after := statusIn(online, u.userID)
+	if before == after {
+		return
+	}
+	room, _ := rooms.FromKey(u.roomKey)
+	s.broadcastEvent(u.roomKey, protocol.TypePresence, protocol.PresenceChanged{
+		Room:   room.String(),
+		UserID: u.userID,
+		Status: after,
+	})
+}
+
+// localAway reports whether the user still has connections to the room on
+// this instance, and whether all of them are idle. It runs on Run.
+func (s *Server) localAway(userID, roomKey string) (connected, away bool) {
+	away = true
+	for c := range s.clients {
+		if c.UserID == userID && c.rooms[roomKey] {
+			connected = true
+			away = away && c.away
+		}
+	}
+	return connected, away
+}
+
+// joinPresence records a new connection to the room and acks the join frame
+// once it is recorded. Join marks the user active, so the away flag is left
+// alone. It runs on Run.
+func (s *Server) joinPresence(client *Client, id, roomKey string) {
+	s.presence <- presenceUpdate{
+		userID:  client.UserID,
+		roomKey: roomKey,
+		change: func(ctx context.Context) error {
+			return s.fanout.Join(ctx, roomKey, client.UserID)
+		},
+		joined: &joinAck{client: client, id: id, roomKey: roomKey},
+	}
+}
+
+// ackJoin acks a join whose presence update was applied, then sends the
+// first history page. It runs on Run.
+func (s *Server) ackJoin(a joinAck) {
+	if !s.clients[a.client] || !a.client.rooms[a.roomKey] {
+		return
+	}
+	room, _ := rooms.FromKey(a.roomKey)
+	a.client.ack(a.id, protocol.Ack{Room: room.String(), Online: a.online})
+	go s.sendHistoryPage(a.client, "", a.roomKey, "", 0)
+}
+
+// leavePresence ends the client's typing in the room and drops one of the
+// user's connections to it. It runs on Run, after the client has left.
+func (s *Server) leavePresence(client *Client, roomKey string) {
+	s.stopTyping(client, roomKey)
+	connected, away := s.localAway(client.UserID, roomKey)
+	s.presence <- presenceUpdate{
+		userID:  client.UserID,
+		roomKey: roomKey,
+		change: func(ctx context.Context) error {
+			return s.fanout.Leave(ctx, roomKey, client.UserID)
+		},
+		setAway: connected,
+		away:    away,
+	}
+}
+
+func (s *Server) applyStatusChange(change statusChange) {
+	client := change.client
+	if !s.clients[client] || client.away == change.away {
+		return
+	}
+	client.away = change.away
+	for roomKey := range client.rooms {
+		_, away := s.localAway(client.UserID, roomKey)
+		s.presence <- presenceUpdate{
+			userID:  client.UserID,
+			roomKey: roomKey,
+			setAway: true,
+			away:    away,
+		}
+	}
+}
+
+// OnlineUsers returns the users connected to the room on any instance.
+func (s *Server) OnlineUsers(ctx context.Context, roomKey string) (map[string]string, error) {
+	online, err := s.fanout.Online(ctx, roomKey)
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[string]string, len(online))
+	for _, p := range online {
+		out[p.UserID] = protocol.StatusOnline
+		if p.Away {
+			out[p.UserID] = protocol.StatusAway
+		}
+	}
+	return out, nil
+}
+
+// startTyping handles typing.start. Repeats within typingThrottle only
+// extend the expiry.
+func (s *Server) startTyping(client *Client, roomKey string) {
+	now := time.Now()
+	key := typingKey{roomKey: roomKey, userID: client.UserID}
+
+	s.typingMu.Lock()
+	state, ok := s.typing[key]
+	if !ok {
+		state = &typingState{user: protocol.Author{ID: client.UserID, Name: client.Username}}
+		s.typing[key] = state
+	}
+	state.expires = now.Add(typingTTL)
+	throttled := now.Sub(state.lastSent) < typingThrottle
+	if !throttled {
+		state.lastSent = now
+	}
+	user := state.user
+	s.typingMu.Unlock()
+
+	if !throttled {
+		s.broadcastTyping(roomKey, user, true)
+	}
+}
+
+// stopTyping ends the client's typing in the room. Message handlers call it
+// from client workers as well as from Run.
+func (s *Server) stopTyping(client *Client, roomKey string) {
+	key := typingKey{roomKey: roomKey, userID: client.UserID}
+	s.typingMu.Lock()
+	state, ok := s.typing[key]
+	delete(s.typing, key)
+	s.typingMu.Unlock()
+
+	if ok {
+		s.broadcastTyping(roomKey, state.user, false)
+	}
+}
+
+// expireTyping ends typing that was not refreshed in time.
+func (s *Server) expireTyping(now time.Time) {
+	var expired []typingKey
+	var users []protocol.Author
+	s.typingMu.Lock()
+	for key, state := range s.typing {
+		if now.After(state.expires) {
+			delete(s.typing, key)
+			expired = append(expired, key)
+			users = append(users, state.user)
+		}
+	}
+	s.typingMu.Unlock()
+
+	for i, key := range expired {
+		s.broadcastTyping(key.roomKey, users[i], false)
+	}
+}
+
+func (s *Server) broadcastTyping(roomKey string, user protocol.Author, typing bool) {
+	room, _ := rooms.FromKey(roomKey)
+	s.broadcastEvent(roomKey, protocol.TypeTyping, protocol.Typing{
+		Room:   room.String(),
+		User:   user,
+		Typing: typing,
+	})
+}
+
+// GroupPresence lists the group's members with their current chat status.
+func (h *ChatHandler) GroupPresence(c *gin.Context) {
+	ctx := c.Request.Context()
+	log := logging.Logger(ctx)
+
+	ctx, span := chatTracer.Start(ctx, "chat.presence.list")
+	defer span.End()
+
+	groupID, err := uuid.Parse(c.Param("groupId"))
+	if err != nil {
+		span.AddEvent("invalid_group_id")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid group ID"})
+		return
+	}
+
+	room := rooms.Room{Kind: rooms.Group, GroupID: groupID}
+	span.SetAttributes(attribute.String("room.id", room.String()))
+
+	var members []struct {
+		UserID   uuid.UUID `json:"user_id"`
+		Username string    `json:"username"`
+		Status   string    `json:"status" gorm:"-"`
+	}
+	if err := config.DB.WithContext(ctx).Table("users").
+		Select("users.id AS user_id, users.username").
+		Joins("JOIN group_members ON group_members.user_id = users.id").
+		Where("group_members.group_id = ? AND group_members.status = ?", groupID, "joined").
+		Order("users.username").
+		Scan(&members).Error; err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to fetch members")
+		log.Error("failed to fetch group members", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch presence"})
+		return
+	}
+
+	online, err := h.server.OnlineUsers(ctx, room.Key())
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to read presence")
+		log.Error("failed to read presence", zap.String("room_id", room.Key()), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch presence"})
+		return
+	}
+
+	counts := map[string]int{}
+	for i := range members {
+		status, ok := online[members[i].UserID.String()]
+		if !ok {
+			status = protocol.StatusOffline
+		}
+		members[i].Status = status
+		counts[status]++
+	}
+
+	span.SetAttributes(attribute.Int("members.online", counts[protocol.StatusOnline]))
+	span.SetStatus(codes.Ok, "presence listed")
+	c.JSON(http.StatusOK, gin.H{
+		"room":    room.String(),
+		"members": members,
+		"online":  counts[protocol.StatusOnline],
+		"away":    counts[protocol.StatusAway],
+	})
+}
@@ -0,0 +1,147 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"unicode"
+	"unicode/utf8"
+
+	"core-service/config"
+	"core-service/internal/chat/protocol"
+	"core-service/internal/chat/rooms"
+	"core-service/models"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// maxEmojiLength matches the emoji column; it leaves room for skin tones
+// and joined sequences.
+const maxEmojiLength = 32
+
+// validEmoji accepts short strings without spaces or control characters.
+// Which emoji clients offer is up to them.
+func validEmoji(emoji string) bool {
+	if emoji == "" || len(emoji) > maxEmojiLength || !utf8.ValidString(emoji) {
+		return false
+	}
+	for _, r := range emoji {
+		if unicode.IsSpace(r) || unicode.IsControl(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// react adds (or, for unreact, removes) the client's reaction and tells
+// the room the emoji's new count. Repeating either is acked without an
+// event.
+func (s *Server) react(client *Client, req *ClientMessage) {
+	added := req.Type == protocol.TypeReact
+
+	ctx, span := chatTracer.Start(context.Background(), "chat.message."+req.Type)
+	defer span.End()
+
+	room, _ := rooms.FromKey(req.Room)
+	span.SetAttributes(
+		attribute.String("room.id", req.Room),
+		attribute.String("user.id", client.UserID),
+		attribute.String("message.id", req.MessageID),
+	)
+
+	if !validEmoji(req.Emoji) {
+		client.sendError(req.ID, protocol.CodeInvalidFrame, room.String(), "Invalid emoji")
+		return
+	}
+	messageID, err := uuid.Parse(req.MessageID)
+	if err != nil {
+		client.sendError(req.ID, protocol.CodeNotFound, room.String(), "Message not found")
+		return
+	}
+	userID, _ := uuid.Parse(client.UserID)
+
+	var msg models.ChatMessage
+	err = config.DB.WithContext(ctx).Select("id").
+		First(&msg, "id = ? AND room_id = ? AND deleted_at IS NULL", messageID, req.Room).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		client.sendError(req.ID, protocol.CodeNotFound, room.String(), "Message not found")
+		return
+	}
+
+	var changed, count int64
+	if err == nil {
+		err = config.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			var result *gorm.DB
+			if added {
+				result = tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.MessageReaction{
+					MessageID: msg.ID,
+					UserID:    userID,
+					Emoji:     req.Emoji,
+				})
+			} else {
+				result = tx.Where("message_id = ? AND user_id = ? AND emoji = ?", msg.ID, userID, req.Emoji).
+					Delete(&models.MessageReaction{})
+			}
+			if result.Error != nil {
+				return result.Error
+			}
+			changed = result.RowsAffected
+			return tx.Model(&models.MessageReaction{}).
+				Where("message_id = ? AND emoji = ?", msg.ID, req.Emoji).
+				Count(&count).Error
+		})
+	}
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "reaction update failed")
+		client.log.Error("failed to update reaction", zap.String("room_id", req.Room), zap.Error(err))
+		client.sendError(req.ID, protocol.CodeInternal, room.String(), "Could not update reaction")
+		return
+	}
+
+	if changed > 0 {
+		s.broadcastEvent(req.Room, protocol.TypeReaction, protocol.ReactionUpdated{
+			Room:      room.String(),
+			MessageID: msg.ID.String(),
+			UserID:    client.UserID,
+			Emoji:     req.Emoji,
+			Added:     added,
+			Count:     int(count),
+		})
+	}
+	span.SetStatus(codes.Ok, "reaction updated")
+	client.ack(req.ID, protocol.Ack{Room: room.String(), MessageID: msg.ID.String()})
+}
+
+// loadReactions returns the reactions on each of the given messages, keyed
+// by message ID, with emoji in the order they were first used.
+func loadReactions(ctx context.Context, messageIDs []uuid.UUID) (map[string][]protocol.Reaction, error) {
+	var rows []models.MessageReaction
+	if err := config.DB.WithContext(ctx).
+		Where("message_id IN ?", messageIDs).
+		Order("created_at, id").
+		Find(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	out := make(map[string][]protocol.Reaction)
+	for _, r := range rows {
+		key := r.MessageID.String()
+		reactions := out[key]
+		i := 0
+		for i < len(reactions) && reactions[i].Emoji != r.Emoji {
+			i++
+		}
+		if i == len(reactions) {
+			reactions = append(reactions, protocol.Reaction{Emoji: r.Emoji})
+		}
+		reactions[i].Count++
+		reactions[i].Users = append(reactions[i].Users, r.UserID.String())
+		out[key] = reactions
+	}
+	return out, nil
+}
@@ -0,0 +1,191 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"core-service/config"
+	"core-service/internal/chat/protocol"
+	"core-service/internal/chat/rooms"
+	"core-service/internal/observability/logging"
+	"core-service/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// markRead moves the client's read cursor forward and tells the room.
+// Reading an older message than the cursor is acked but changes nothing.
+func (s *Server) markRead(client *Client, req *ClientMessage) {
+	ctx, span := chatTracer.Start(context.Background(), "chat.message.read")
+	defer span.End()
+
+	room, _ := rooms.FromKey(req.Room)
+	span.SetAttributes(
+		attribute.String("room.id", req.Room),
+		attribute.String("user.id", client.UserID),
+		attribute.String("message.id", req.MessageID),
+	)
+
+	messageID, err := uuid.Parse(req.MessageID)
+	if err != nil {
+		client.sendError(req.ID, protocol.CodeNotFound, room.String(), "Message not found")
+		return
+	}
+	userID, _ := uuid.Parse(client.UserID)
+
+	var msg models.ChatMessage
+	err = config.DB.WithContext(ctx).Select("id", "timestamp").
+		First(&msg, "id = ? AND room_id = ?", messageID, req.Room).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		client.sendError(req.ID, protocol.CodeNotFound, room.String(), "Message not found")
+		return
+	}
+	if err != nil {
+		span.RecordError(err)
+		client.log.Error("failed to look up read message", zap.Error(err))
+		client.sendError(req.ID, protocol.CodeInternal, room.String(), "Could not update read position")
+		return
+	}
+
+	now := time.Now()
+	result := config.DB.WithContext(ctx).Exec(
+		`INSERT INTO chat_read_cursors (user_id, room_id, message_id, message_timestamp, read_at)
+		 VALUES (?, ?, ?, ?, ?)
+		 ON CONFLICT (user_id, room_id) DO UPDATE
+		 SET message_id = EXCLUDED.message_id,
+		     message_timestamp = EXCLUDED.message_timestamp,
+		     read_at = EXCLUDED.read_at
+		 WHERE (chat_read_cursors.message_timestamp, chat_read_cursors.message_id)
+		     < (EXCLUDED.message_timestamp, EXCLUDED.message_id)`,
+		userID, req.Room, msg.ID, msg.Timestamp, now,
+	)
+	if result.Error != nil {
+		span.RecordError(result.Error)
+		span.SetStatus(codes.Error, "cursor update failed")
+		client.log.Error("failed to update read cursor", zap.Error(result.Error))
+		client.sendError(req.ID, protocol.CodeInternal, room.String(), "Could not update read position")
+		return
+	}
+
+	if result.RowsAffected > 0 {
+		s.broadcastEvent(req.Room, protocol.TypeReceipt, protocol.Receipt{
+			Room:      room.String(),
+			UserID:    client.UserID,
+			MessageID: msg.ID.String(),
+			ReadAt:    now,
+		})
+	}
+	span.SetStatus(codes.Ok, "read cursor updated")
+	client.ack(req.ID, protocol.Ack{Room: room.String(), MessageID: msg.ID.String()})
+}
+
+// ListReadCursors returns how far each member has read a group's chat.
+func (h *ChatHandler) ListReadCursors(c *gin.Context) {
+	ctx := c.Request.Context()
+	log := logging.Logger(ctx)
+
+	ctx, span := chatTracer.Start(ctx, "chat.receipts.list")
+	defer span.End()
+
+	groupID, err := uuid.Parse(c.Param("groupId"))
+	if err != nil {
+		span.AddEvent("invalid_group_id")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid group ID"})
+		return
+	}
+
+	room := rooms.Room{Kind: rooms.Group, GroupID: groupID}
+	span.SetAttributes(attribute.String("room.id", room.String()))
+
+	var cursors []models.ChatReadCursor
+	if err := config.DB.WithContext(ctx).
+		Joins("JOIN group_members ON group_members.user_id = chat_read_cursors.user_id").
+		Where("chat_read_cursors.room_id = ? AND group_members.group_id = ? AND group_members.status = ?",
+			room.Key(), groupID, "joined").
+		Order("chat_read_cursors.message_timestamp desc").
+		Find(&cursors).Error; err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "cursor query failed")
+		log.Error("failed to fetch read cursors", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch read receipts"})
+		return
+	}
+
+	span.SetStatus(codes.Ok, "read cursors listed")
+	c.JSON(http.StatusOK, gin.H{"room": room.String(), "receipts": cursors})
+}
+
+// GetUnreadCounts returns, for every group the user belongs to, how many
+// messages from others arrived after their read cursor (or after they
+// joined, if they never read the room) and, counted separately, how many of
+// those mention them.
+func GetUnreadCounts(c *gin.Context) {
+	ctx := c.Request.Context()
+	log := logging.Logger(ctx)
+
+	ctx, span := chatTracer.Start(ctx, "chat.unread.list")
+	defer span.End()
+
+	user := c.MustGet("user").(models.User)
+	span.SetAttributes(attribute.String("user.id", user.ID.String()))
+
+	var counts []struct {
+		GroupID           uuid.UUID  `json:"group_id"`
+		GroupName         string     `json:"group_name"`
+		Room              string     `json:"room" gorm:"-"`
+		LastReadMessageID *uuid.UUID `json:"last_read_message_id"`
+		Unread            int64      `json:"unread"`
+		Mentions          int64      `json:"mentions"`
+	}
+	if err := config.DB.WithContext(ctx).Raw(
+		`SELECT g.id AS group_id, g.name AS group_name,
+		        rc.message_id AS last_read_message_id,
+		        COUNT(m.id) AS unread,
+		        COUNT(cm.message_id) AS mentions
+		 FROM group_members gm
+		 JOIN groups g ON g.id = gm.group_id
+		 LEFT JOIN chat_read_cursors rc
+		        ON rc.user_id = gm.user_id AND rc.room_id = gm.group_id::text
+		 LEFT JOIN chat_messages m
+		        ON m.room_id = gm.group_id::text
+		       AND m.user_id <> gm.user_id
+		       AND m.deleted_at IS NULL
+		       AND (CASE WHEN rc.user_id IS NULL
+		                 THEN m.timestamp > gm.joined_at
+		                 ELSE (m.timestamp, m.id) > (rc.message_timestamp, rc.message_id)
+		            END)
+		 LEFT JOIN chat_mentions cm
+		        ON cm.message_id = m.id AND cm.user_id = gm.user_id
+		 WHERE gm.user_id = ? AND gm.status = ?
+		 GROUP BY g.id, g.name, rc.message_id
+		 ORDER BY g.name`,
+		user.ID, "joined",
+	).Scan(&counts).Error; err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "unread query failed")
+		log.Error("failed to count unread messages", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch unread counts"})
+		return
+	}
+
+	var total, mentions int64
+	for i := range counts {
+		counts[i].Room = rooms.Room{Kind: rooms.Group, GroupID: counts[i].GroupID}.String()
+		total += counts[i].Unread
+		mentions += counts[i].Mentions
+	}
+
+	span.SetAttributes(
+		attribute.Int64("messages.unread", total),
+		attribute.Int64("messages.mentions", mentions),
+	)
+	span.SetStatus(codes.Ok, "unread counted")
+	c.JSON(http.StatusOK, gin.H{"rooms": counts, "total_unread": total, "total_mentions": mentions})
+}
@@ -0,0 +1,289 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"core-service/config"
+	"core-service/internal/chat/protocol"
+	"core-service/internal/chat/rooms"
+	"core-service/internal/observability/logging"
+	"core-service/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// threadRoot returns the top-level message of the thread that messageID
+// belongs to: the message itself, or its parent if it is a reply.
+func threadRoot(ctx context.Context, roomKey, messageID string) (*models.ChatMessage, error) {
+	return findThreadRoot(ctx, roomKey, messageID, false)
+}
+
+// replyRoot is threadRoot for a new reply. Deleted messages cannot be
+// replied to, and neither can replies whose root was deleted.
+func replyRoot(ctx context.Context, roomKey, messageID string) (*models.ChatMessage, error) {
+	return findThreadRoot(ctx, roomKey, messageID, true)
+}
+
+func findThreadRoot(ctx context.Context, roomKey, messageID string, liveOnly bool) (*models.ChatMessage, error) {
+	id, err := uuid.Parse(messageID)
+	if err != nil {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	query := func() *gorm.DB {
+		q := config.DB.WithContext(ctx).Preload("User").Preload("Attachments")
+		if liveOnly {
+			q = q.Where("deleted_at IS NULL")
+		}
+		return q
+	}
+
+	var msg models.ChatMessage
+	if err := query().First(&msg, "id = ? AND room_id = ?", id, roomKey).Error; err != nil {
+		return nil, err
+	}
+	if msg.ParentID == nil {
+		return &msg, nil
+	}
+
+	var root models.ChatMessage
+	if err := query().First(&root, "id = ? AND room_id = ?", *msg.ParentID, roomKey).Error; err != nil {
+		return nil, err
+	}
+	return &root, nil
+}
+
+type threadSummary struct {
+	ParentID   uuid.UUID
+	ID         uuid.UUID
+	UserID     uuid.UUID
+	Username   string
+	Timestamp  time.Time
+	ReplyCount int
+}
+
+// loadThreadSummaries returns the reply count and newest reply of each of
+// the given messages that has replies, keyed by message ID.
+func loadThreadSummaries(ctx context.Context, parentIDs []uuid.UUID) (map[string]threadSummary, error) {
+	var rows []threadSummary
+	if err := config.DB.WithContext(ctx).Raw(
+		`SELECT DISTINCT ON (m.parent_id)
+		        m.parent_id, m.id, m.user_id, u.username, m.timestamp,
+		        COUNT(*) OVER (PARTITION BY m.parent_id) AS reply_count
+		 FROM chat_messages m
+		 JOIN users u ON u.id = m.user_id
+		 WHERE m.parent_id IN ? AND m.deleted_at IS NULL
+		 ORDER BY m.parent_id, m.timestamp DESC, m.id DESC`,
+		parentIDs,
+	).Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	out := make(map[string]threadSummary, len(rows))
+	for _, r := range rows {
+		out[r.ParentID.String()] = r
+	}
+	return out, nil
+}
+
+// toServerMessages converts stored messages for clients, adding reactions
+// and, on top-level messages, thread metadata. Messages are still returned
+// without them if they cannot be loaded.
+func (s *Server) toServerMessages(ctx context.Context, msgs []models.ChatMessage) []*protocol.Message {
+	out := make([]*protocol.Message, 0, len(msgs))
+	var ids, parents, pollIDs []uuid.UUID
+	for i := range msgs {
+		out = append(out, s.toServerMessage(&msgs[i]))
+		if msgs[i].DeletedAt == nil {
+			ids = append(ids, msgs[i].ID)
+			if msgs[i].Kind == models.MessagePoll {
+				pollIDs = append(pollIDs, msgs[i].ID)
+			}
+		}
+		if msgs[i].ParentID == nil {
+			parents = append(parents, msgs[i].ID)
+		}
+	}
+
+	if len(ids) > 0 {
+		reactions, err := loadReactions(ctx, ids)
+		if err != nil {
+			s.log.Warn("failed to load reactions", zap.Error(err))
+		}
+		for _, m := range out {
+			m.Reactions = reactions[m.ID]
+		}
+	}
+
+	if len(pollIDs) > 0 {
+		loaded, err := loadPolls(ctx, pollIDs)
+		if err != nil {
+			s.log.Warn("failed to load polls", zap.Error(err))
+		}
+		for _, m := range out {
+			m.Poll = loaded[m.ID]
+		}
+	}
+
+	if len(parents) == 0 {
+		return out
+	}
+	summaries, err := loadThreadSummaries(ctx, parents)
+	if err != nil {
+		s.log.Warn("failed to load thread summaries", zap.Error(err))
+		return out
+	}
+	for _, m := range out {
+		t, ok := summaries[m.ID]
+		if !ok {
+			continue
+		}
+		m.ReplyCount = t.ReplyCount
+		m.LatestReply = &protocol.ReplySummary{
+			ID:        t.ID.String(),
+			User:      protocol.Author{ID: t.UserID.String(), Name: t.Username},
+			Timestamp: t.Timestamp,
+		}
+	}
+	return out
+}
+
+// broadcastThreadUpdate sends the thread's parent, with its new reply
+// count, to the room after a reply was added or removed.
+func (s *Server) broadcastThreadUpdate(ctx context.Context, roomKey string, parentID uuid.UUID) {
+	var parent models.ChatMessage
+	if err := config.DB.WithContext(ctx).Preload("User").Preload("Attachments").
+		First(&parent, "id = ?", parentID).Error; err != nil {
+		s.log.Warn("failed to reload thread parent", zap.String("message_id", parentID.String()), zap.Error(err))
+		return
+	}
+
+	room, _ := rooms.FromKey(roomKey)
+	s.broadcastEvent(roomKey, protocol.TypeMessageUpdated, protocol.MessageUpdated{
+		Room:    room.String(),
+		Message: s.toServerMessages(ctx, []models.ChatMessage{parent})[0],
+	})
+}
+
+// loadReplies returns up to limit replies to parentID newer than the reply
+// after (or the oldest ones when after is nil), oldest first. The returned
+// cursor is nil once the newest reply is reached.
+func loadReplies(ctx context.Context, parentID uuid.UUID, after *uuid.UUID, limit int) ([]models.ChatMessage, *uuid.UUID, error) {
+	ctx, span := chatTracer.Start(ctx, "chat.thread.page")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("message.id", parentID.String()),
+		attribute.Int("limit", limit),
+	)
+
+	q := config.DB.WithContext(ctx).Preload("User").
+		Preload("Attachments").
+		Where("parent_id = ?", parentID)
+
+	if after != nil {
+		var cursor models.ChatMessage
+		err := config.DB.WithContext(ctx).Select("id", "timestamp").
+			First(&cursor, "id = ? AND parent_id = ?", *after, parentID).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, errUnknownCursor
+		}
+		if err != nil {
+			span.RecordError(err)
+			return nil, nil, err
+		}
+		q = q.Where("(timestamp, id) > (?, ?)", cursor.Timestamp, cursor.ID)
+	}
+
+	var replies []models.ChatMessage
+	if err := q.Order("timestamp asc, id asc").
+		Limit(limit + 1).
+		Find(&replies).Error; err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "db query failed")
+		return nil, nil, err
+	}
+
+	var next *uuid.UUID
+	if len(replies) > limit {
+		replies = replies[:limit]
+		newest := replies[limit-1].ID
+		next = &newest
+	}
+
+	span.SetAttributes(attribute.Int("messages.count", len(replies)))
+	return replies, next, nil
+}
+
+// ListReplies pages forwards through the thread of a message. Given a
+// reply, it returns the thread that reply belongs to.
+func (h *ChatHandler) ListReplies(c *gin.Context) {
+	ctx := c.Request.Context()
+	log := logging.Logger(ctx)
+
+	ctx, span := chatTracer.Start(ctx, "chat.thread.list")
+	defer span.End()
+
+	groupID, err := uuid.Parse(c.Param("groupId"))
+	if err != nil {
+		span.AddEvent("invalid_group_id")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid group ID"})
+		return
+	}
+
+	room := rooms.Room{Kind: rooms.Group, GroupID: groupID}
+	span.SetAttributes(
+		attribute.String("room.id", room.String()),
+		attribute.String("message.id", c.Param("messageId")),
+	)
+
+	after, limit, err := parseHistoryParams(c.Query("after"), c.Query("limit"))
+	if err != nil {
+		span.AddEvent("invalid_params")
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	parent, err := threadRoot(ctx, room.Key(), c.Param("messageId"))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		span.AddEvent("message_not_found")
+		c.JSON(http.StatusNotFound, gin.H{"error": "Message not found"})
+		return
+	}
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "parent lookup failed")
+		log.Error("failed to look up thread parent", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch replies"})
+		return
+	}
+
+	replies, next, err := loadReplies(ctx, parent.ID, after, limit)
+	if errors.Is(err, errUnknownCursor) {
+		span.AddEvent("unknown_cursor")
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "thread query failed")
+		log.Error("failed to fetch thread replies", zap.String("message_id", parent.ID.String()), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch replies"})
+		return
+	}
+
+	span.SetStatus(codes.Ok, "thread listed")
+	c.JSON(http.StatusOK, protocol.ThreadPage{
+		Parent:     h.server.toServerMessages(ctx, []models.ChatMessage{*parent})[0],
+		Replies:    h.server.toServerMessages(ctx, replies),
+		NextCursor: cursorString(next),
+	})
+}
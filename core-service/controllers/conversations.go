@@ -0,0 +1,394 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+	"unicode/utf8"
+
+	"core-service/config"
+	"core-service/internal/chat/protocol"
+	"core-service/internal/chat/rooms"
+	"core-service/internal/observability/logging"
+	"core-service/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var conversationTracer = otel.Tracer("controllers.conversations")
+
+const (
+	// maxConversationSize caps small-group conversations, creator included.
+	maxConversationSize = 8
+	// previewLength is how many characters of a message are quoted in
+	// conversation lists and notifications.
+	previewLength = 120
+)
+
+func previewText(text string) string {
+	if utf8.RuneCountInString(text) > previewLength {
+		return string([]rune(text)[:previewLength]) + "…"
+	}
+	return text
+}
+
+type conversationPreview struct {
+	ID        uuid.UUID       `json:"id"`
+	User      protocol.Author `json:"user"`
+	Text      string          `json:"text"`
+	Timestamp time.Time       `json:"timestamp"`
+	Deleted   bool            `json:"deleted,omitempty"`
+}
+
+type conversationView struct {
+	ID           uuid.UUID            `json:"id"`
+	Kind         string               `json:"kind"`
+	Title        string               `json:"title,omitempty"`
+	Room         string               `json:"room"`
+	Participants []protocol.Author    `json:"participants"`
+	LastMessage  *conversationPreview `json:"last_message"`
+	CreatedAt    time.Time            `json:"created_at"`
+}
+
+func newConversationView(conv *models.Conversation) conversationView {
+	view := conversationView{
+		ID:           conv.ID,
+		Kind:         conv.Kind,
+		Title:        conv.Title,
+		Room:         conv.RoomKey,
+		Participants: make([]protocol.Author, 0, len(conv.Participants)),
+		CreatedAt:    conv.CreatedAt,
+	}
+	for _, p := range conv.Participants {
+		view.Participants = append(view.Participants, protocol.Author{ID: p.UserID.String(), Name: p.User.Username})
+	}
+	return view
+}
+
+// ensureDirectConversation returns the conversation of a direct room,
+// creating it on first use. It reports whether it was created.
+func ensureDirectConversation(ctx context.Context, room rooms.Room, createdBy uuid.UUID) (*models.Conversation, bool, error) {
+	created, err := recordDirectConversation(ctx, room, createdBy)
+	if err != nil {
+		return nil, false, err
+	}
+
+	var out models.Conversation
+	if err := config.DB.WithContext(ctx).Preload("Participants.User").
+		First(&out, "room_key = ?", room.Key()).Error; err != nil {
+		return nil, false, err
+	}
+	return &out, created, nil
+}
+
+// recordDirectConversation creates the conversation of a direct room if it
+// does not exist yet, without loading it. It reports whether it was created.
+func recordDirectConversation(ctx context.Context, room rooms.Room, createdBy uuid.UUID) (bool, error) {
+	created := false
+	conv := models.Conversation{
+		Kind:      models.ConversationDirect,
+		RoomKey:   room.Key(),
+		CreatedBy: createdBy,
+		CreatedAt: time.Now(),
+	}
+
+	err := config.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "room_key"}}, DoNothing: true}).
+			Create(&conv)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+
+		created = true
+		for _, userID := range room.Users {
+			if err := tx.Create(&models.ConversationParticipant{
+				ConversationID: conv.ID,
+				UserID:         userID,
+				JoinedAt:       conv.CreatedAt,
+			}).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return created, err
+}
+
+// recordDirectRoom lists a direct room under both users' conversations the
+// first time a message is sent to it. Each hub checks this once, so later
+// messages skip the database.
+func (s *Server) recordDirectRoom(client *Client, room rooms.Room) {
+	hub, ok := s.getHub(room.Key())
+	if ok && hub.conversationRecorded.Load() {
+		return
+	}
+	if _, err := recordDirectConversation(context.Background(), room, uuid.MustParse(client.UserID)); err != nil {
+		client.log.Warn("failed to record direct conversation", zap.Error(err))
+		return
+	}
+	if ok {
+		hub.conversationRecorded.Store(true)
+	}
+}
+
+// pairsWithoutSharedGroup returns the pairs of users, in list order, that
+// are not both joined members of any one group.
+func pairsWithoutSharedGroup(ctx context.Context, userIDs []uuid.UUID) ([][2]uuid.UUID, error) {
+	var rows []struct {
+		A uuid.UUID
+		B uuid.UUID
+	}
+	if err := config.DB.WithContext(ctx).Table("group_members AS a").
+		Select("a.user_id AS a, b.user_id AS b").
+		Joins("JOIN group_members AS b ON b.group_id = a.group_id").
+		Where("a.user_id IN ? AND b.user_id IN ? AND a.user_id <> b.user_id", userIDs, userIDs).
+		Where("a.status = ? AND b.status = ?", "joined", "joined").
+		Group("a.user_id, b.user_id").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	shared := make(map[[2]uuid.UUID]bool, len(rows))
+	for _, r := range rows {
+		shared[[2]uuid.UUID{r.A, r.B}] = true
+	}
+
+	var missing [][2]uuid.UUID
+	for i, a := range userIDs {
+		for _, b := range userIDs[i+1:] {
+			if !shared[[2]uuid.UUID{a, b}] {
+				missing = append(missing, [2]uuid.UUID{a, b})
+			}
+		}
+	}
+	return missing, nil
+}
+
+// CreateConversation opens a conversation with the given users. Every pair
+// of participants, the creator included, must share at least one group.
+// Asking again for an existing one-to-one conversation returns it.
+func CreateConversation(c *gin.Context) {
+	ctx := c.Request.Context()
+	log := logging.Logger(ctx)
+
+	ctx, span := conversationTracer.Start(ctx, "conversation.create")
+	defer span.End()
+
+	userID := c.MustGet("user_id").(uuid.UUID)
+	span.SetAttributes(attribute.String("user.id", userID.String()))
+
+	var input struct {
+		UserIDs []uuid.UUID `json:"user_ids" binding:"required,min=1"`
+		Title   string      `json:"title"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		span.AddEvent("invalid_payload")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "user_ids must list at least one user"})
+		return
+	}
+
+	seen := map[uuid.UUID]bool{userID: true}
+	var others []uuid.UUID
+	for _, id := range input.UserIDs {
+		if !seen[id] {
+			seen[id] = true
+			others = append(others, id)
+		}
+	}
+	span.SetAttributes(attribute.Int("participants.count", len(others)+1))
+
+	if len(others) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Add at least one other user"})
+		return
+	}
+	if len(others)+1 > maxConversationSize {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Conversations are limited to %d people", maxConversationSize)})
+		return
+	}
+
+	var found int64
+	if err := config.DB.WithContext(ctx).Model(&models.User{}).Where("id IN ?", others).Count(&found).Error; err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "user lookup failed")
+		log.Error("failed to look up conversation participants", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create conversation"})
+		return
+	}
+	if int(found) != len(others) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	missing, err := pairsWithoutSharedGroup(ctx, append([]uuid.UUID{userID}, others...))
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "mutual group lookup failed")
+		log.Error("failed to check shared groups", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create conversation"})
+		return
+	}
+	for _, pair := range missing {
+		span.AddEvent("no_shared_group", trace.WithAttributes(
+			attribute.String("user_a.id", pair[0].String()),
+			attribute.String("user_b.id", pair[1].String()),
+		))
+		if pair[0] == userID || pair[1] == userID {
+			c.JSON(http.StatusForbidden, gin.H{"error": "You can only message people who share a group with you"})
+		} else {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Everyone in a conversation must share a group with each other"})
+		}
+		return
+	}
+
+	if len(others) == 1 {
+		conv, created, err := ensureDirectConversation(ctx, rooms.NewDirect(userID, others[0]), userID)
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "direct conversation failed")
+			log.Error("failed to create direct conversation", zap.Error(err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create conversation"})
+			return
+		}
+		status := http.StatusOK
+		if created {
+			status = http.StatusCreated
+		}
+		span.SetStatus(codes.Ok, "direct conversation ready")
+		c.JSON(status, gin.H{"conversation": newConversationView(conv)})
+		return
+	}
+
+	now := time.Now()
+	conv := models.Conversation{
+		ID:        uuid.New(),
+		Kind:      models.ConversationGroup,
+		Title:     input.Title,
+		CreatedBy: userID,
+		CreatedAt: now,
+	}
+	conv.RoomKey = rooms.Room{Kind: rooms.Conversation, ConversationID: conv.ID}.Key()
+	for _, id := range append([]uuid.UUID{userID}, others...) {
+		conv.Participants = append(conv.Participants, models.ConversationParticipant{UserID: id, JoinedAt: now})
+	}
+
+	if err := config.DB.WithContext(ctx).Create(&conv).Error; err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "db insert failed")
+		log.Error("failed to create conversation", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create conversation"})
+		return
+	}
+	if err := config.DB.WithContext(ctx).Preload("Participants.User").First(&conv, "id = ?", conv.ID).Error; err != nil {
+		span.RecordError(err)
+		log.Error("failed to reload conversation", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create conversation"})
+		return
+	}
+
+	log.Info("conversation created", zap.String("conversation_id", conv.ID.String()))
+	span.SetStatus(codes.Ok, "conversation created")
+	c.JSON(http.StatusCreated, gin.H{"conversation": newConversationView(&conv)})
+}
+
+// ListConversations returns the user's conversations with a preview of
+// their last message, most recently active first.
+func ListConversations(c *gin.Context) {
+	ctx := c.Request.Context()
+	log := logging.Logger(ctx)
+
+	ctx, span := conversationTracer.Start(ctx, "conversation.list")
+	defer span.End()
+
+	userID := c.MustGet("user_id").(uuid.UUID)
+	span.SetAttributes(attribute.String("user.id", userID.String()))
+
+	var convs []models.Conversation
+	if err := config.DB.WithContext(ctx).Preload("Participants.User").
+		Joins("JOIN conversation_participants cp ON cp.conversation_id = conversations.id").
+		Where("cp.user_id = ?", userID).
+		Find(&convs).Error; err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "conversation query failed")
+		log.Error("failed to fetch conversations", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch conversations"})
+		return
+	}
+
+	views := make([]conversationView, 0, len(convs))
+	keys := make([]string, 0, len(convs))
+	for i := range convs {
+		views = append(views, newConversationView(&convs[i]))
+		keys = append(keys, convs[i].RoomKey)
+	}
+
+	if len(keys) > 0 {
+		var last []struct {
+			ID        uuid.UUID
+			RoomID    string
+			UserID    uuid.UUID
+			Username  string
+			Text      string
+			Timestamp time.Time
+			Deleted   bool
+		}
+		if err := config.DB.WithContext(ctx).Raw(
+			`SELECT DISTINCT ON (m.room_id)
+			        m.id, m.room_id, m.user_id, u.username, m.text, m.timestamp,
+			        m.deleted_at IS NOT NULL AS deleted
+			 FROM chat_messages m
+			 JOIN users u ON u.id = m.user_id
+			 WHERE m.room_id IN ? AND m.parent_id IS NULL
+			 ORDER BY m.room_id, m.timestamp DESC, m.id DESC`,
+			keys,
+		).Scan(&last).Error; err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "preview query failed")
+			log.Error("failed to fetch conversation previews", zap.Error(err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch conversations"})
+			return
+		}
+
+		previews := make(map[string]*conversationPreview, len(last))
+		for _, m := range last {
+			text := m.Text
+			if m.Deleted {
+				text = ""
+			}
+			previews[m.RoomID] = &conversationPreview{
+				ID:        m.ID,
+				User:      protocol.Author{ID: m.UserID.String(), Name: m.Username},
+				Text:      previewText(text),
+				Timestamp: m.Timestamp,
+				Deleted:   m.Deleted,
+			}
+		}
+		for i := range views {
+			views[i].LastMessage = previews[views[i].Room]
+		}
+	}
+
+	lastActive := func(v conversationView) time.Time {
+		if v.LastMessage != nil {
+			return v.LastMessage.Timestamp
+		}
+		return v.CreatedAt
+	}
+	sort.Slice(views, func(i, j int) bool { return lastActive(views[i]).After(lastActive(views[j])) })
+
+	span.SetAttributes(attribute.Int("conversations.count", len(views)))
+	span.SetStatus(codes.Ok, "conversations listed")
+	c.JSON(http.StatusOK, gin.H{"conversations": views})
+}
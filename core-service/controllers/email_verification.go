@@ -0,0 +1,157 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"core-service/config"
+	"core-service/internal/mail"
+	"core-service/models"
+	"core-service/utils"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"core-service/internal/observability/logging"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+)
+
+const emailVerificationTTL = 48 * time.Hour
+
+// sendVerificationEmail mails a fresh verification link for the user's
+// current address and invalidates any earlier links.
+func sendVerificationEmail(ctx context.Context, user *models.User) error {
+	plain, err := utils.GenerateOpaqueToken()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	err = config.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.EmailVerificationToken{}).
+			Where("user_id = ? AND used_at IS NULL", user.ID).
+			Update("used_at", now).Error; err != nil {
+			return err
+		}
+		return tx.Create(&models.EmailVerificationToken{
+			UserID:    user.ID,
+			Email:     user.Email,
+			TokenHash: utils.HashToken(plain),
+			CreatedAt: now,
+			ExpiresAt: now.Add(emailVerificationTTL),
+		}).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	link := fmt.Sprintf("%s/auth/verify-email?token=%s", config.FrontendURL(), url.QueryEscape(plain))
+	return config.Mailer.Send(ctx, mail.Message{
+		To:      user.Email,
+		Subject: "Confirm your StudyCollab email",
+		Body: fmt.Sprintf(
+			"Hi %s,\n\nPlease confirm your email address by opening the link below:\n\n%s\n\nThe link expires in %d hours.\n",
+			user.Username, link, int(emailVerificationTTL.Hours()),
+		),
+	})
+}
+
+func VerifyEmail(c *gin.Context) {
+	ctx := c.Request.Context()
+	log := logging.Logger(ctx)
+
+	ctx, span := tracer.Start(ctx, "auth.verify_email")
+	defer span.End()
+
+	var input struct {
+		Token string `json:"token" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		span.AddEvent("invalid_payload")
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var token models.EmailVerificationToken
+	if err := config.DB.WithContext(ctx).
+		First(&token, "token_hash = ?", utils.HashToken(input.Token)).Error; err != nil {
+
+		span.AddEvent("unknown_verification_token")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired verification link"})
+		return
+	}
+
+	span.SetAttributes(attribute.String("user.id", token.UserID.String()))
+
+	now := time.Now()
+	verified := false
+	err := config.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.EmailVerificationToken{}).
+			Where("id = ? AND used_at IS NULL AND expires_at > ?", token.ID, now).
+			Update("used_at", now)
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+
+		// The address must still be the one the link was sent to.
+		result = tx.Model(&models.User{}).
+			Where("id = ? AND email = ?", token.UserID, token.Email).
+			Update("verified_at", now)
+		if result.Error != nil {
+			return result.Error
+		}
+		verified = result.RowsAffected == 1
+		return nil
+	})
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "verification failed")
+		log.Error("email verification failed", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not verify email"})
+		return
+	}
+
+	if !verified {
+		span.AddEvent("verification_token_spent")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired verification link"})
+		return
+	}
+
+	span.SetStatus(codes.Ok, "email verified")
+	log.Info("email verified", zap.String("user_id", token.UserID.String()))
+	c.JSON(http.StatusOK, gin.H{"message": "Email verified"})
+}
+
+func ResendVerificationEmail(c *gin.Context) {
+	ctx := c.Request.Context()
+	log := logging.Logger(ctx)
+
+	ctx, span := tracer.Start(ctx, "auth.resend_verification")
+	defer span.End()
+
+	user := c.MustGet("user").(models.User)
+	span.SetAttributes(attribute.String("user.id", user.ID.String()))
+
+	if user.VerifiedAt != nil {
+		span.AddEvent("already_verified")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Email is already verified"})
+		return
+	}
+
+	if err := sendVerificationEmail(ctx, &user); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "verification email failed")
+		log.Error("failed to send verification email", zap.String("user_id", user.ID.String()), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not send verification email"})
+		return
+	}
+
+	span.SetStatus(codes.Ok, "verification email sent")
+	c.JSON(http.StatusOK, gin.H{"message": "Verification email sent"})
+}
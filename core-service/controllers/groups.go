@@ -4,6 +4,7 @@ import (
 	"core-service/config"
 	"core-service/internal/observability/logging"
 	"core-service/internal/observability/metrics"
+	"core-service/internal/permissions"
 	"core-service/models"
 	"net/http"
 	"time"
@@ -68,8 +69,8 @@ func CreateGroup(c *gin.Context) {
 
 		if err := tx.Exec(
 			`INSERT INTO group_members (user_id, group_id, status, role, joined_at)
-			 VALUES (?, ?, 'joined', 'admin', ?)`,
-			userID, groupID, time.Now(),
+			 VALUES (?, ?, 'joined', ?, ?)`,
+			userID, groupID, permissions.Owner, time.Now(),
 		).Error; err != nil {
 			return err
 		}
@@ -312,22 +313,6 @@ func GetMembers(c *gin.Context) {
 		return
 	}
 
-	var membership models.GroupMember
-	if err := config.DB.WithContext(ctx).First(
-		&membership,
-		"group_id = ? AND user_id = ? AND status = ?",
-		groupID, currentUserID, "joined",
-	).Error; err != nil {
-
-		span.AddEvent("non_member_access")
-		log.Warn("non-member attempted to view members",
-			zap.String("group_id", groupID.String()),
-			zap.String("user_id", currentUserID.String()),
-		)
-		c.JSON(http.StatusForbidden, gin.H{"error": "You must be a member of this group"})
-		return
-	}
-
 	var groupMembers []struct {
 		UserID   uuid.UUID
 		Status   string
@@ -411,9 +396,10 @@ func GetMembers(c *gin.Context) {
 	span.SetStatus(codes.Ok, "members fetched")
 
 	c.JSON(http.StatusOK, gin.H{
-		"group_id": group.ID,
-		"members":  members,
-		"count":    len(members),
+		"group_id":        group.ID,
+		"members":         members,
+		"count":           len(members),
+		"current_user_id": currentUserID,
 	})
 }
 
@@ -497,22 +483,11 @@ func UpdateGroupMember(c *gin.Context) {
 		attribute.String("member.id", memberID.String()),
 	)
 
-	var admin models.GroupMember
-	if err := config.DB.WithContext(ctx).First(
-		&admin,
-		"group_id = ? AND user_id = ? AND role = ?",
-		groupID, currentUserID, "admin",
-	).Error; err != nil {
-
-		span.AddEvent("admin_check_failed")
-		log.Warn("non-admin attempted member update")
-		c.JSON(http.StatusForbidden, gin.H{"error": "Only admins can update members"})
-		return
-	}
+	actor := c.MustGet("group_member").(models.GroupMember)
 
 	if currentUserID == memberID {
 		span.AddEvent("self_modification_attempt")
-		c.JSON(http.StatusForbidden, gin.H{"error": "Admins cannot modify themselves"})
+		c.JSON(http.StatusForbidden, gin.H{"error": "You cannot modify your own membership"})
 		return
 	}
 
@@ -545,6 +520,18 @@ func UpdateGroupMember(c *gin.Context) {
 		return
 	}
 
+	if !permissions.Outranks(actor.Role, member.Role) {
+		span.AddEvent("target_outranks_actor")
+		c.JSON(http.StatusForbidden, gin.H{"error": "You can only manage members with a lower role than yours"})
+		return
+	}
+
+	if body.Role != nil && !permissions.CanAssign(actor.Role, member.Role, *body.Role) {
+		span.AddEvent("role_not_assignable")
+		c.JSON(http.StatusForbidden, gin.H{"error": "You cannot assign this role"})
+		return
+	}
+
 	prevStatus := member.Status
 
 	tx := config.DB.WithContext(ctx).Begin()
@@ -604,6 +591,10 @@ func UpdateGroupMember(c *gin.Context) {
 		return
 	}
 
+	if prevStatus == "joined" && body.Status != nil && *body.Status != "joined" {
+		membershipRevoked(groupID, memberID)
+	}
+
 	span.SetStatus(codes.Ok, "member updated")
 
 	log.Info("group member updated",
@@ -651,23 +642,6 @@ func UpdateGroupDetails(c *gin.Context) {
 		return
 	}
 
-	var member models.GroupMember
-	if err := config.DB.WithContext(ctx).First(
-		&member,
-		"group_id = ? AND user_id = ? AND role = 'admin'",
-		groupIDStr, userID,
-	).Error; err != nil {
-
-		span.AddEvent("admin_check_failed")
-		log.Warn(
-			"non-admin attempted group update",
-			zap.String("group_id", groupIDStr),
-			zap.String("user_id", userID.String()),
-		)
-		c.JSON(http.StatusForbidden, gin.H{"error": "You are not authorized to update this group"})
-		return
-	}
-
 	if body.Name != "" && body.Name != group.Name {
 		var existing models.Group
 		if err := config.DB.WithContext(ctx).First(&existing, "name = ?", body.Name).Error; err == nil {
@@ -0,0 +1,16 @@
+package controllers
+
+import (
+	"net/http"
+
+	"core-service/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// JWKS publishes the public halves of the token signing keys so other
+// services can verify access tokens without sharing a secret.
+func JWKS(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, utils.Keys().JWKS())
+}
@@ -0,0 +1,156 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"core-service/config"
+	"core-service/internal/auth/lockout"
+	"core-service/internal/mail"
+	"core-service/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"core-service/internal/observability/logging"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+)
+
+// Account keys use the submitted email rather than the user ID so that
+// guesses against unknown addresses are throttled the same way.
+func accountKey(email string) string {
+	return "account:" + strings.ToLower(strings.TrimSpace(email))
+}
+
+func ipKey(ip string) string {
+	return "ip:" + ip
+}
+
+// loginThrottled answers 429 when the account or the client address must
+// wait before trying again. Store errors fail open: an outage of the
+// throttle store should not stop everyone from signing in.
+func loginThrottled(c *gin.Context, ctx context.Context, email string) bool {
+	log := logging.Logger(ctx)
+
+	var wait time.Duration
+	for _, check := range []struct {
+		limiter *lockout.Limiter
+		key     string
+	}{
+		{config.AccountLimiter, accountKey(email)},
+		{config.IPLimiter, ipKey(c.ClientIP())},
+	} {
+		d, err := check.limiter.RetryAfter(ctx, check.key)
+		if err != nil {
+			log.Error("login throttle lookup failed", zap.Error(err))
+			continue
+		}
+		if d > wait {
+			wait = d
+		}
+	}
+
+	if wait <= 0 {
+		return false
+	}
+
+	seconds := int(math.Ceil(wait.Seconds()))
+	c.Header("Retry-After", strconv.Itoa(seconds))
+	c.JSON(http.StatusTooManyRequests, gin.H{
+		"error":       "Too many failed attempts, please try again later",
+		"retry_after": seconds,
+	})
+	return true
+}
+
+// recordLoginFailure counts a failed password or second-factor attempt.
+// user is nil when the email is not registered.
+func recordLoginFailure(c *gin.Context, ctx context.Context, email string, user *models.User) {
+	log := logging.Logger(ctx)
+
+	if _, err := config.IPLimiter.Failure(ctx, ipKey(c.ClientIP())); err != nil {
+		log.Error("failed to record login failure", zap.Error(err))
+	}
+
+	res, err := config.AccountLimiter.Failure(ctx, accountKey(email))
+	if err != nil {
+		log.Error("failed to record login failure", zap.Error(err))
+		return
+	}
+	if !res.Locked || user == nil {
+		return
+	}
+
+	log.Warn("account locked after failed logins",
+		zap.String("user_id", user.ID.String()),
+		zap.Int("failures", res.Failures),
+	)
+	if err := config.Mailer.Send(ctx, mail.Message{
+		To:      user.Email,
+		Subject: "Sign-in to your StudyCollab account was locked",
+		Body: fmt.Sprintf(
+			"Hi %s,\n\nAfter %d failed sign-in attempts we have blocked sign-in to your account for %d minutes.\n\nIf this was not you, someone may be guessing your password. Consider resetting it:\n\n%s/auth/forgot-password\n",
+			user.Username, res.Failures, int(res.RetryAfter.Minutes()), config.FrontendURL(),
+		),
+	}); err != nil {
+		log.Error("failed to send lockout email", zap.String("user_id", user.ID.String()), zap.Error(err))
+	}
+}
+
+// clearLoginFailures resets the account after a successful login. The
+// client address keeps its count so one valid account cannot be used to
+// launder guesses against others.
+func clearLoginFailures(ctx context.Context, email string) {
+	if err := config.AccountLimiter.Reset(ctx, accountKey(email)); err != nil {
+		logging.Logger(ctx).Error("failed to reset login failures", zap.Error(err))
+	}
+}
+
+func UnlockUser(c *gin.Context) {
+	ctx := c.Request.Context()
+	log := logging.Logger(ctx)
+
+	ctx, span := tracer.Start(ctx, "admin.unlock_user")
+	defer span.End()
+
+	admin := c.MustGet("user_id").(uuid.UUID)
+
+	userID, err := uuid.Parse(c.Param("userId"))
+	if err != nil {
+		span.AddEvent("invalid_user_id")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	span.SetAttributes(
+		attribute.String("user.id", userID.String()),
+		attribute.String("admin.id", admin.String()),
+	)
+
+	var user models.User
+	if err := config.DB.WithContext(ctx).First(&user, "id = ?", userID).Error; err != nil {
+		span.AddEvent("user_not_found")
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	if err := config.AccountLimiter.Reset(ctx, accountKey(user.Email)); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "unlock failed")
+		log.Error("failed to unlock user", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not unlock user"})
+		return
+	}
+
+	span.SetStatus(codes.Ok, "user unlocked")
+	log.Info("user unlocked by admin", zap.String("user_id", userID.String()), zap.String("admin_id", admin.String()))
+	c.JSON(http.StatusOK, gin.H{"message": "User unlocked"})
+}
@@ -0,0 +1,426 @@
+package controllers
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"net/http"
+	"strings"
+	"time"
+
+	"core-service/config"
+	"core-service/internal/auth/totp"
+	"core-service/models"
+	"core-service/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"core-service/internal/observability/logging"
+	"core-service/internal/observability/metrics"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+)
+
+const (
+	totpIssuer         = "StudyCollab"
+	mfaChallengeTTL    = 5 * time.Minute
+	mfaMaxAttempts     = 5
+	recoveryCodeCount  = 10
+	recoveryCodeLength = 16
+)
+
+// createMFAChallenge starts the second login step for a user whose password
+// (or identity provider) has already been verified.
+func createMFAChallenge(ctx context.Context, userID uuid.UUID) (string, error) {
+	plain, err := utils.GenerateOpaqueToken()
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	challenge := models.MFAChallenge{
+		UserID:    userID,
+		TokenHash: utils.HashToken(plain),
+		CreatedAt: now,
+		ExpiresAt: now.Add(mfaChallengeTTL),
+	}
+	if err := config.DB.WithContext(ctx).Create(&challenge).Error; err != nil {
+		return "", err
+	}
+	return plain, nil
+}
+
+// normalizeRecoveryCode strips the separators and case users tend to type.
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToUpper(code)
+	return strings.NewReplacer("-", "", " ", "").Replace(code)
+}
+
+// generateRecoveryCodes replaces the user's recovery codes and returns the
+// new ones formatted for display. They cannot be shown again later.
+func generateRecoveryCodes(tx *gorm.DB, userID uuid.UUID) ([]string, error) {
+	if err := tx.Where("user_id = ?", userID).Delete(&models.RecoveryCode{}).Error; err != nil {
+		return nil, err
+	}
+
+	enc := base32.StdEncoding.WithPadding(base32.NoPadding)
+	codes := make([]string, 0, recoveryCodeCount)
+	rows := make([]models.RecoveryCode, 0, recoveryCodeCount)
+
+	for i := 0; i < recoveryCodeCount; i++ {
+		b := make([]byte, 10)
+		if _, err := rand.Read(b); err != nil {
+			return nil, err
+		}
+		raw := enc.EncodeToString(b)[:recoveryCodeLength]
+
+		parts := make([]string, 0, recoveryCodeLength/4)
+		for j := 0; j < len(raw); j += 4 {
+			parts = append(parts, raw[j:j+4])
+		}
+		codes = append(codes, strings.Join(parts, "-"))
+		rows = append(rows, models.RecoveryCode{
+			UserID:    userID,
+			CodeHash:  utils.HashToken(raw),
+			CreatedAt: time.Now(),
+		})
+	}
+
+	if err := tx.Create(&rows).Error; err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+func EnrollTOTP(c *gin.Context) {
+	ctx := c.Request.Context()
+	log := logging.Logger(ctx)
+
+	ctx, span := tracer.Start(ctx, "auth.totp.enroll")
+	defer span.End()
+
+	user := c.MustGet("user").(models.User)
+	span.SetAttributes(attribute.String("user.id", user.ID.String()))
+
+	if user.TOTPEnabledAt != nil {
+		span.AddEvent("already_enabled")
+		c.JSON(http.StatusConflict, gin.H{"error": "Two-factor authentication is already enabled"})
+		return
+	}
+	// Turning two-factor off again asks for the password, so accounts
+	// created through an identity provider need one first.
+	if user.Password == "" {
+		span.AddEvent("no_password")
+		c.JSON(http.StatusConflict, gin.H{"error": "Set a password before enabling two-factor authentication"})
+		return
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "secret generation failed")
+		log.Error("totp secret generation failed", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not start enrollment"})
+		return
+	}
+
+	if err := config.DB.WithContext(ctx).Model(&user).Updates(map[string]interface{}{
+		"totp_secret":    secret,
+		"totp_last_step": 0,
+	}).Error; err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "db update failed")
+		log.Error("failed to store totp secret", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not start enrollment"})
+		return
+	}
+
+	span.SetStatus(codes.Ok, "totp enrollment started")
+	c.JSON(http.StatusOK, gin.H{
+		"secret":           secret,
+		"provisioning_uri": totp.ProvisioningURI(totpIssuer, user.Email, secret),
+	})
+}
+
+func ConfirmTOTP(c *gin.Context) {
+	ctx := c.Request.Context()
+	log := logging.Logger(ctx)
+
+	ctx, span := tracer.Start(ctx, "auth.totp.confirm")
+	defer span.End()
+
+	user := c.MustGet("user").(models.User)
+	span.SetAttributes(attribute.String("user.id", user.ID.String()))
+
+	var input struct {
+		Code string `json:"code" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		span.AddEvent("invalid_payload")
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if user.TOTPEnabledAt != nil || user.TOTPSecret == "" {
+		span.AddEvent("no_pending_enrollment")
+		c.JSON(http.StatusConflict, gin.H{"error": "No pending two-factor enrollment"})
+		return
+	}
+
+	step, ok := totp.Validate(user.TOTPSecret, input.Code, time.Now(), 1)
+	if !ok {
+		span.AddEvent("invalid_code")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid code"})
+		return
+	}
+
+	var recoveryCodes []string
+	err := config.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&user).Updates(map[string]interface{}{
+			"totp_enabled_at": time.Now(),
+			"totp_last_step":  step,
+		}).Error; err != nil {
+			return err
+		}
+		var err error
+		recoveryCodes, err = generateRecoveryCodes(tx, user.ID)
+		return err
+	})
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "totp enable failed")
+		log.Error("failed to enable totp", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not enable two-factor authentication"})
+		return
+	}
+
+	span.SetStatus(codes.Ok, "totp enabled")
+	log.Info("two-factor authentication enabled", zap.String("user_id", user.ID.String()))
+	c.JSON(http.StatusOK, gin.H{"recovery_codes": recoveryCodes})
+}
+
+func DisableTOTP(c *gin.Context) {
+	ctx := c.Request.Context()
+	log := logging.Logger(ctx)
+
+	ctx, span := tracer.Start(ctx, "auth.totp.disable")
+	defer span.End()
+
+	user := c.MustGet("user").(models.User)
+	span.SetAttributes(attribute.String("user.id", user.ID.String()))
+
+	var input struct {
+		Password string `json:"password" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		span.AddEvent("invalid_payload")
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !utils.CheckPasswordHash(input.Password, user.Password) {
+		span.AddEvent("invalid_password")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Password is incorrect"})
+		return
+	}
+
+	err := config.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&user).Updates(map[string]interface{}{
+			"totp_secret":     "",
+			"totp_enabled_at": nil,
+			"totp_last_step":  0,
+		}).Error; err != nil {
+			return err
+		}
+		return tx.Where("user_id = ?", user.ID).Delete(&models.RecoveryCode{}).Error
+	})
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "totp disable failed")
+		log.Error("failed to disable totp", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not disable two-factor authentication"})
+		return
+	}
+
+	span.SetStatus(codes.Ok, "totp disabled")
+	log.Info("two-factor authentication disabled", zap.String("user_id", user.ID.String()))
+	c.JSON(http.StatusOK, gin.H{"message": "Two-factor authentication disabled"})
+}
+
+func RegenerateRecoveryCodes(c *gin.Context) {
+	ctx := c.Request.Context()
+	log := logging.Logger(ctx)
+
+	ctx, span := tracer.Start(ctx, "auth.totp.recovery_codes")
+	defer span.End()
+
+	user := c.MustGet("user").(models.User)
+	span.SetAttributes(attribute.String("user.id", user.ID.String()))
+
+	var input struct {
+		Password string `json:"password" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		span.AddEvent("invalid_payload")
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if user.TOTPEnabledAt == nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "Two-factor authentication is not enabled"})
+		return
+	}
+	if !utils.CheckPasswordHash(input.Password, user.Password) {
+		span.AddEvent("invalid_password")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Password is incorrect"})
+		return
+	}
+
+	var recoveryCodes []string
+	err := config.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		recoveryCodes, err = generateRecoveryCodes(tx, user.ID)
+		return err
+	})
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "recovery code generation failed")
+		log.Error("failed to regenerate recovery codes", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not generate recovery codes"})
+		return
+	}
+
+	span.SetStatus(codes.Ok, "recovery codes regenerated")
+	c.JSON(http.StatusOK, gin.H{"recovery_codes": recoveryCodes})
+}
+
+// VerifyMFA completes a login started by Login (or an identity provider)
+// for an account with two-factor enabled. Either a current TOTP code or an
+// unused recovery code is accepted.
+func VerifyMFA(c *gin.Context) {
+	ctx := c.Request.Context()
+	log := logging.Logger(ctx)
+
+	ctx, span := tracer.Start(ctx, "auth.mfa.verify")
+	defer span.End()
+
+	var input struct {
+		MFAToken     string `json:"mfa_token" binding:"required"`
+		Code         string `json:"code"`
+		RecoveryCode string `json:"recovery_code"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil || (input.Code == "" && input.RecoveryCode == "") {
+		span.AddEvent("invalid_payload")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "mfa_token and a code or recovery_code are required"})
+		return
+	}
+
+	now := time.Now()
+
+	var challenge models.MFAChallenge
+	if err := config.DB.WithContext(ctx).First(
+		&challenge,
+		"token_hash = ? AND used_at IS NULL AND expires_at > ? AND attempts < ?",
+		utils.HashToken(input.MFAToken), now, mfaMaxAttempts,
+	).Error; err != nil {
+		metrics.AuthLoginFailure.Add(ctx, 1)
+		span.AddEvent("invalid_challenge")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Login challenge expired, please sign in again"})
+		return
+	}
+
+	span.SetAttributes(attribute.String("user.id", challenge.UserID.String()))
+
+	var user models.User
+	if err := config.DB.WithContext(ctx).First(&user, "id = ?", challenge.UserID).Error; err != nil || user.TOTPEnabledAt == nil {
+		span.AddEvent("user_not_enrolled")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Login challenge expired, please sign in again"})
+		return
+	}
+
+	// Second-factor guesses count against the same budget as passwords,
+	// so fresh challenges cannot be used to keep guessing codes.
+	if loginThrottled(c, ctx, user.Email) {
+		metrics.AuthLoginFailure.Add(ctx, 1)
+		span.AddEvent("login_throttled")
+		return
+	}
+
+	verified := false
+	err := config.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if input.Code != "" {
+			step, ok := totp.Validate(user.TOTPSecret, input.Code, now, 1)
+			if !ok {
+				return nil
+			}
+			// Each time step is accepted once, so an observed code cannot
+			// be replayed within its validity window.
+			result := tx.Model(&models.User{}).
+				Where("id = ? AND totp_last_step < ?", user.ID, step).
+				Update("totp_last_step", step)
+			if result.Error != nil {
+				return result.Error
+			}
+			verified = result.RowsAffected == 1
+			return nil
+		}
+
+		result := tx.Model(&models.RecoveryCode{}).
+			Where("user_id = ? AND code_hash = ? AND used_at IS NULL", user.ID, utils.HashToken(normalizeRecoveryCode(input.RecoveryCode))).
+			Update("used_at", now)
+		if result.Error != nil {
+			return result.Error
+		}
+		verified = result.RowsAffected > 0
+		return nil
+	})
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "mfa verification failed")
+		log.Error("mfa verification failed", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not verify code"})
+		return
+	}
+
+	if !verified {
+		metrics.AuthLoginFailure.Add(ctx, 1)
+		recordLoginFailure(c, ctx, user.Email, &user)
+		span.AddEvent("invalid_code")
+		if err := config.DB.WithContext(ctx).Model(&challenge).
+			UpdateColumn("attempts", gorm.Expr("attempts + 1")).Error; err != nil {
+			log.Error("failed to count mfa attempt", zap.Error(err))
+		}
+		log.Warn("mfa verification failed", zap.String("user_id", user.ID.String()))
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid code"})
+		return
+	}
+
+	result := config.DB.WithContext(ctx).Model(&models.MFAChallenge{}).
+		Where("id = ? AND used_at IS NULL", challenge.ID).
+		Update("used_at", now)
+	if result.Error != nil || result.RowsAffected == 0 {
+		span.AddEvent("challenge_already_used")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Login challenge expired, please sign in again"})
+		return
+	}
+
+	if _, err := startSession(c, ctx, user.ID); err != nil {
+		metrics.AuthLoginFailure.Add(ctx, 1)
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "session creation failed")
+		log.Error("session creation failed", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+		return
+	}
+
+	clearLoginFailures(ctx, user.Email)
+
+	span.SetStatus(codes.Ok, "login successful")
+	metrics.AuthLoginSuccess.Add(ctx, 1)
+	log.Info("user logged in with second factor", zap.String("user_id", user.ID.String()))
+	c.JSON(http.StatusOK, gin.H{"message": "Login successful"})
+}
@@ -0,0 +1,119 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"core-service/config"
+	"core-service/internal/observability/logging"
+	"core-service/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.uber.org/zap"
+)
+
+var notificationTracer = otel.Tracer("controllers.notifications")
+
+const (
+	notificationDefaultLimit = 50
+	notificationMaxLimit     = 200
+)
+
+// ListNotifications returns the user's newest notifications, only unread
+// ones with ?unread=true, along with the number still unread.
+func ListNotifications(c *gin.Context) {
+	ctx := c.Request.Context()
+	log := logging.Logger(ctx)
+
+	ctx, span := notificationTracer.Start(ctx, "notification.list")
+	defer span.End()
+
+	userID := c.MustGet("user_id").(uuid.UUID)
+	span.SetAttributes(attribute.String("user.id", userID.String()))
+
+	limit := notificationDefaultLimit
+	if raw := c.Query("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be a positive number"})
+			return
+		}
+		limit = min(n, notificationMaxLimit)
+	}
+
+	q := config.DB.WithContext(ctx).Where("user_id = ?", userID)
+	if c.Query("unread") == "true" {
+		q = q.Where("read_at IS NULL")
+	}
+
+	notifications := []models.Notification{}
+	if err := q.Order("created_at desc").Limit(limit).Find(&notifications).Error; err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "notification query failed")
+		log.Error("failed to fetch notifications", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch notifications"})
+		return
+	}
+
+	var unread int64
+	if err := config.DB.WithContext(ctx).Model(&models.Notification{}).
+		Where("user_id = ? AND read_at IS NULL", userID).
+		Count(&unread).Error; err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "notification count failed")
+		log.Error("failed to count notifications", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch notifications"})
+		return
+	}
+
+	span.SetAttributes(attribute.Int64("notifications.unread", unread))
+	span.SetStatus(codes.Ok, "notifications listed")
+	c.JSON(http.StatusOK, gin.H{"notifications": notifications, "unread": unread})
+}
+
+// MarkNotificationsRead marks the given notifications, or all of them when
+// no IDs are sent, as read.
+func MarkNotificationsRead(c *gin.Context) {
+	ctx := c.Request.Context()
+	log := logging.Logger(ctx)
+
+	ctx, span := notificationTracer.Start(ctx, "notification.read")
+	defer span.End()
+
+	userID := c.MustGet("user_id").(uuid.UUID)
+	span.SetAttributes(attribute.String("user.id", userID.String()))
+
+	var input struct {
+		IDs []uuid.UUID `json:"ids"`
+	}
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&input); err != nil {
+			span.AddEvent("invalid_payload")
+			c.JSON(http.StatusBadRequest, gin.H{"error": "ids must be a list of notification IDs"})
+			return
+		}
+	}
+
+	q := config.DB.WithContext(ctx).Model(&models.Notification{}).
+		Where("user_id = ? AND read_at IS NULL", userID)
+	if len(input.IDs) > 0 {
+		q = q.Where("id IN ?", input.IDs)
+	}
+	result := q.Update("read_at", time.Now())
+	if result.Error != nil {
+		span.RecordError(result.Error)
+		span.SetStatus(codes.Error, "notification update failed")
+		log.Error("failed to mark notifications read", zap.Error(result.Error))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update notifications"})
+		return
+	}
+
+	span.SetAttributes(attribute.Int64("notifications.read", result.RowsAffected))
+	span.SetStatus(codes.Ok, "notifications read")
+	c.JSON(http.StatusOK, gin.H{"read": result.RowsAffected})
+}
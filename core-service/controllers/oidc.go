@@ -0,0 +1,436 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"core-service/config"
+	"core-service/internal/auth/oidc"
+	"core-service/models"
+	"core-service/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"core-service/internal/observability/logging"
+	"core-service/internal/observability/metrics"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+)
+
+var oidcTracer = otel.Tracer("controllers.oidc")
+
+const (
+	oidcStateCookie = "oidc_state"
+	oidcStatePath   = "/auth/oidc"
+	oidcStateTTL    = 10 * time.Minute
+)
+
+var (
+	errOIDCEmailInUse = errors.New("email belongs to an account that cannot be linked")
+	usernameCleaner   = regexp.MustCompile(`[^a-z0-9_.-]+`)
+)
+
+// OIDCHandler serves the authorization code flow for the configured
+// OpenID Connect providers.
+type OIDCHandler struct {
+	Providers map[string]*oidc.Provider
+}
+
+func NewOIDCHandler(providers []*oidc.Provider) *OIDCHandler {
+	h := &OIDCHandler{Providers: make(map[string]*oidc.Provider, len(providers))}
+	for _, p := range providers {
+		h.Providers[p.Name()] = p
+	}
+	return h
+}
+
+// ListProviders lets the login page render a button per provider.
+func (h *OIDCHandler) ListProviders(c *gin.Context) {
+	names := make([]string, 0, len(h.Providers))
+	for name := range h.Providers {
+		names = append(names, name)
+	}
+	c.JSON(http.StatusOK, gin.H{"providers": names})
+}
+
+// Login starts the flow: it records state, nonce and PKCE verifier and
+// redirects the browser to the provider.
+func (h *OIDCHandler) Login(c *gin.Context) {
+	ctx := c.Request.Context()
+	log := logging.Logger(ctx)
+
+	ctx, span := oidcTracer.Start(ctx, "oidc.login")
+	defer span.End()
+
+	name := c.Param("provider")
+	span.SetAttributes(attribute.String("oidc.provider", name))
+
+	provider, ok := h.Providers[name]
+	if !ok {
+		span.AddEvent("unknown_provider")
+		c.JSON(http.StatusNotFound, gin.H{"error": "Unknown login provider"})
+		return
+	}
+
+	var state, nonce, verifier string
+	var err error
+	for _, v := range []*string{&state, &nonce, &verifier} {
+		if *v, err = oidc.RandomString(); err != nil {
+			break
+		}
+	}
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "random generation failed")
+		log.Error("oidc state generation failed", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start login"})
+		return
+	}
+
+	authURL, err := provider.AuthCodeURL(ctx, state, nonce, verifier)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "provider discovery failed")
+		log.Error("oidc discovery failed", zap.String("provider", name), zap.Error(err))
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Login provider is unavailable"})
+		return
+	}
+
+	now := time.Now()
+	if err := config.DB.WithContext(ctx).Create(&models.OIDCLoginState{
+		StateHash:    utils.HashToken(state),
+		Provider:     name,
+		Nonce:        nonce,
+		CodeVerifier: verifier,
+		RedirectTo:   safeRedirectPath(c.Query("redirect")),
+		CreatedAt:    now,
+		ExpiresAt:    now.Add(oidcStateTTL),
+	}).Error; err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "state store failed")
+		log.Error("failed to store oidc state", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start login"})
+		return
+	}
+
+	// Binding the state to the browser as well as the database stops a
+	// callback URL from being replayed in somebody else's browser.
+	c.SetCookie(oidcStateCookie, state, int(oidcStateTTL.Seconds()), oidcStatePath, "localhost", false, true)
+
+	span.SetStatus(codes.Ok, "redirected to provider")
+	c.Redirect(http.StatusFound, authURL)
+}
+
+// Callback finishes the flow. Failures send the browser back to the login
+// page with an error code instead of rendering JSON.
+func (h *OIDCHandler) Callback(c *gin.Context) {
+	ctx := c.Request.Context()
+	log := logging.Logger(ctx)
+
+	ctx, span := oidcTracer.Start(ctx, "oidc.callback")
+	defer span.End()
+
+	name := c.Param("provider")
+	span.SetAttributes(attribute.String("oidc.provider", name))
+
+	provider, ok := h.Providers[name]
+	if !ok {
+		span.AddEvent("unknown_provider")
+		c.JSON(http.StatusNotFound, gin.H{"error": "Unknown login provider"})
+		return
+	}
+
+	fail := func(reason string) {
+		metrics.AuthLoginFailure.Add(ctx, 1)
+		oidcLoginRedirect(c, url.Values{"error": {reason}})
+	}
+
+	cookieState, _ := c.Cookie(oidcStateCookie)
+	c.SetCookie(oidcStateCookie, "", -1, oidcStatePath, "localhost", false, true)
+
+	if e := c.Query("error"); e != "" {
+		span.SetAttributes(attribute.String("oidc.error", e))
+		span.AddEvent("provider_error")
+		fail("oidc_denied")
+		return
+	}
+
+	state := c.Query("state")
+	if state == "" || state != cookieState {
+		span.AddEvent("state_mismatch")
+		log.Warn("oidc state mismatch", zap.String("provider", name))
+		fail("oidc_state")
+		return
+	}
+
+	now := time.Now()
+	var login models.OIDCLoginState
+	err := config.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&login, "state_hash = ? AND provider = ? AND expires_at > ?",
+			utils.HashToken(state), name, now).Error; err != nil {
+			return err
+		}
+		// A state is single use whatever the outcome.
+		return tx.Delete(&login).Error
+	})
+	if err != nil {
+		span.AddEvent("unknown_state")
+		fail("oidc_state")
+		return
+	}
+
+	tokens, err := provider.Exchange(ctx, c.Query("code"), login.CodeVerifier)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "code exchange failed")
+		log.Warn("oidc code exchange failed", zap.String("provider", name), zap.Error(err))
+		fail("oidc_exchange")
+		return
+	}
+
+	claims, err := provider.VerifyIDToken(ctx, tokens.IDToken, login.Nonce)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "id token rejected")
+		log.Warn("oidc id token rejected", zap.String("provider", name), zap.Error(err))
+		fail("oidc_token")
+		return
+	}
+
+	user, err := resolveOIDCUser(ctx, name, claims, now)
+	if errors.Is(err, errOIDCEmailInUse) {
+		span.AddEvent("email_in_use")
+		fail("oidc_email_in_use")
+		return
+	}
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "user resolution failed")
+		log.Error("oidc user resolution failed", zap.String("provider", name), zap.Error(err))
+		fail("oidc_failed")
+		return
+	}
+
+	span.SetAttributes(attribute.String("user.id", user.ID.String()))
+
+	if user.TOTPEnabledAt != nil {
+		mfaToken, err := createMFAChallenge(ctx, user.ID)
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "mfa challenge failed")
+			log.Error("mfa challenge creation failed", zap.Error(err))
+			fail("oidc_failed")
+			return
+		}
+		span.AddEvent("mfa_required")
+		oidcLoginRedirect(c, url.Values{"mfa_token": {mfaToken}, "redirect": {login.RedirectTo}})
+		return
+	}
+
+	if _, err := startSession(c, ctx, user.ID); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "session creation failed")
+		log.Error("session creation failed", zap.Error(err))
+		fail("oidc_failed")
+		return
+	}
+
+	span.SetStatus(codes.Ok, "login successful")
+	metrics.AuthLoginSuccess.Add(ctx, 1)
+	log.Info("user logged in", zap.String("user_id", user.ID.String()), zap.String("provider", name))
+	c.Redirect(http.StatusFound, config.FrontendURL()+login.RedirectTo)
+}
+
+// resolveOIDCUser finds the local user for a verified ID token. A known
+// (provider, subject) pair wins; otherwise an account with the same email is
+// linked when both sides have proven ownership of the address, and failing
+// that a new account is created.
+func resolveOIDCUser(ctx context.Context, provider string, claims *oidc.IDClaims, now time.Time) (*models.User, error) {
+	var user models.User
+
+	err := config.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var identity models.Identity
+		err := tx.First(&identity, "provider = ? AND subject = ?", provider, claims.Subject).Error
+		if err == nil {
+			if err := tx.Model(&identity).Update("last_login_at", now).Error; err != nil {
+				return err
+			}
+			return tx.First(&user, "id = ?", identity.UserID).Error
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		email := strings.ToLower(strings.TrimSpace(claims.Email))
+		if email == "" {
+			return fmt.Errorf("provider %s returned no email for subject %s", provider, claims.Subject)
+		}
+
+		err = tx.Where("LOWER(email) = ?", email).First(&user).Error
+		switch {
+		case err == nil:
+			// Linking on an unverified address on either side would let
+			// whoever claimed the email first take over the other account.
+			if !claims.EmailVerified || user.VerifiedAt == nil {
+				return errOIDCEmailInUse
+			}
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			username, err := uniqueUsername(tx, claims)
+			if err != nil {
+				return err
+			}
+			user = models.User{Username: username, Email: email}
+			if claims.EmailVerified {
+				user.VerifiedAt = &now
+			}
+			if err := tx.Create(&user).Error; err != nil {
+				return err
+			}
+		default:
+			return err
+		}
+
+		return tx.Create(&models.Identity{
+			UserID:      user.ID,
+			Provider:    provider,
+			Subject:     claims.Subject,
+			Email:       email,
+			CreatedAt:   now,
+			LastLoginAt: &now,
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// uniqueUsername derives a username from the provider's profile and adds a
+// short suffix when it is already taken.
+func uniqueUsername(tx *gorm.DB, claims *oidc.IDClaims) (string, error) {
+	base := claims.PreferredUsername
+	if base == "" {
+		base, _, _ = strings.Cut(claims.Email, "@")
+	}
+	base = strings.Trim(usernameCleaner.ReplaceAllString(strings.ToLower(base), ""), ".-")
+	if base == "" {
+		base = "user"
+	}
+
+	candidate := base
+	for i := 0; i < 5; i++ {
+		var count int64
+		if err := tx.Model(&models.User{}).Where("username = ?", candidate).Count(&count).Error; err != nil {
+			return "", err
+		}
+		if count == 0 {
+			return candidate, nil
+		}
+		candidate = base + "-" + uuid.NewString()[:6]
+	}
+	return "", fmt.Errorf("could not find a free username for %q", base)
+}
+
+// safeRedirectPath only allows same-site paths so the login flow cannot be
+// used as an open redirect.
+func safeRedirectPath(p string) string {
+	if p == "" || !strings.HasPrefix(p, "/") || strings.HasPrefix(p, "//") || strings.Contains(p, "\\") {
+		return "/"
+	}
+	return p
+}
+
+func oidcLoginRedirect(c *gin.Context, q url.Values) {
+	c.Redirect(http.StatusFound, config.FrontendURL()+"/auth/login?"+q.Encode())
+}
+
+func ListIdentities(c *gin.Context) {
+	ctx := c.Request.Context()
+	log := logging.Logger(ctx)
+
+	ctx, span := oidcTracer.Start(ctx, "oidc.list_identities")
+	defer span.End()
+
+	userID := c.MustGet("user_id").(uuid.UUID)
+	span.SetAttributes(attribute.String("user.id", userID.String()))
+
+	var identities []models.Identity
+	if err := config.DB.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Order("created_at").
+		Find(&identities).Error; err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "identity lookup failed")
+		log.Error("failed to list identities", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not fetch linked accounts"})
+		return
+	}
+
+	span.SetStatus(codes.Ok, "identities listed")
+	c.JSON(http.StatusOK, identities)
+}
+
+// UnlinkIdentity removes a linked provider, unless it is the only way left
+// to sign in to the account.
+func UnlinkIdentity(c *gin.Context) {
+	ctx := c.Request.Context()
+	log := logging.Logger(ctx)
+
+	ctx, span := oidcTracer.Start(ctx, "oidc.unlink_identity")
+	defer span.End()
+
+	user := c.MustGet("user").(models.User)
+	span.SetAttributes(attribute.String("user.id", user.ID.String()))
+
+	identityID, err := uuid.Parse(c.Param("identityId"))
+	if err != nil {
+		span.AddEvent("invalid_identity_id")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid identity ID"})
+		return
+	}
+
+	var count int64
+	if err := config.DB.WithContext(ctx).Model(&models.Identity{}).
+		Where("user_id = ?", user.ID).Count(&count).Error; err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "identity count failed")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not unlink account"})
+		return
+	}
+	if count <= 1 && user.Password == "" {
+		span.AddEvent("last_login_method")
+		c.JSON(http.StatusConflict, gin.H{"error": "Set a password before unlinking your last sign-in provider"})
+		return
+	}
+
+	result := config.DB.WithContext(ctx).
+		Where("id = ? AND user_id = ?", identityID, user.ID).
+		Delete(&models.Identity{})
+	if result.Error != nil {
+		span.RecordError(result.Error)
+		span.SetStatus(codes.Error, "identity delete failed")
+		log.Error("failed to unlink identity", zap.Error(result.Error))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not unlink account"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		span.AddEvent("identity_not_found")
+		c.JSON(http.StatusNotFound, gin.H{"error": "Linked account not found"})
+		return
+	}
+
+	span.SetStatus(codes.Ok, "identity unlinked")
+	log.Info("identity unlinked", zap.String("user_id", user.ID.String()), zap.String("identity_id", identityID.String()))
+	c.JSON(http.StatusOK, gin.H{"message": "Account unlinked"})
+}
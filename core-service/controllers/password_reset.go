@@ -0,0 +1,203 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"core-service/config"
+	"core-service/internal/mail"
+	"core-service/models"
+	"core-service/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"core-service/internal/observability/logging"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+)
+
+const passwordResetTTL = 30 * time.Minute
+
+// ForgotPassword mails a reset link when the email belongs to an account.
+// The response is the same either way so it cannot be used to probe which
+// addresses are registered.
+func ForgotPassword(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	ctx, span := tracer.Start(ctx, "auth.forgot_password")
+	defer span.End()
+
+	var input struct {
+		Email string `json:"email" binding:"required,email"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		span.AddEvent("invalid_payload")
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	accepted := gin.H{"message": "If the email is registered, a reset link has been sent"}
+
+	var user models.User
+	if err := config.DB.WithContext(ctx).Where("email = ?", input.Email).First(&user).Error; err != nil {
+		span.AddEvent("unknown_email")
+		c.JSON(http.StatusOK, accepted)
+		return
+	}
+
+	span.SetAttributes(attribute.String("user.id", user.ID.String()))
+
+	// Issuing and mailing the link happens after responding, so a
+	// registered address takes no longer to answer than an unknown one.
+	go sendPasswordReset(context.WithoutCancel(ctx), user, c.ClientIP())
+
+	span.SetStatus(codes.Ok, "reset requested")
+	c.JSON(http.StatusOK, accepted)
+}
+
+// sendPasswordReset issues a new reset link for the user, retiring any
+// earlier one, and mails it. It runs in the background, so failures are only
+// logged.
+func sendPasswordReset(ctx context.Context, user models.User, requestIP string) {
+	log := logging.Logger(ctx)
+
+	ctx, span := tracer.Start(ctx, "auth.forgot_password.send")
+	defer span.End()
+	span.SetAttributes(attribute.String("user.id", user.ID.String()))
+
+	plain, err := utils.GenerateOpaqueToken()
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "token generation failed")
+		log.Error("reset token generation failed", zap.Error(err))
+		return
+	}
+
+	now := time.Now()
+	err = config.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Only the newest link stays usable.
+		if err := tx.Model(&models.PasswordResetToken{}).
+			Where("user_id = ? AND used_at IS NULL", user.ID).
+			Update("used_at", now).Error; err != nil {
+			return err
+		}
+		return tx.Create(&models.PasswordResetToken{
+			UserID:    user.ID,
+			TokenHash: utils.HashToken(plain),
+			RequestIP: requestIP,
+			CreatedAt: now,
+			ExpiresAt: now.Add(passwordResetTTL),
+		}).Error
+	})
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "token store failed")
+		log.Error("failed to store reset token", zap.Error(err))
+		return
+	}
+
+	link := fmt.Sprintf("%s/auth/reset-password?token=%s", config.FrontendURL(), url.QueryEscape(plain))
+	if err := config.Mailer.Send(ctx, mail.Message{
+		To:      user.Email,
+		Subject: "Reset your StudyCollab password",
+		Body: fmt.Sprintf(
+			"Hi %s,\n\nUse the link below to choose a new password. It expires in %d minutes.\n\n%s\n\nIf you did not ask for this, you can ignore this email.\n",
+			user.Username, int(passwordResetTTL.Minutes()), link,
+		),
+	}); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "mail delivery failed")
+		log.Error("failed to send reset email", zap.String("user_id", user.ID.String()), zap.Error(err))
+		return
+	}
+
+	span.SetStatus(codes.Ok, "reset link sent")
+	log.Info("password reset requested", zap.String("user_id", user.ID.String()))
+}
+
+func ResetPassword(c *gin.Context) {
+	ctx := c.Request.Context()
+	log := logging.Logger(ctx)
+
+	ctx, span := tracer.Start(ctx, "auth.reset_password")
+	defer span.End()
+
+	var input struct {
+		Token       string `json:"token" binding:"required"`
+		NewPassword string `json:"new_password" binding:"required,min=6"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		span.AddEvent("invalid_payload")
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var token models.PasswordResetToken
+	if err := config.DB.WithContext(ctx).
+		First(&token, "token_hash = ?", utils.HashToken(input.Token)).Error; err != nil {
+
+		span.AddEvent("unknown_reset_token")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired reset link"})
+		return
+	}
+
+	span.SetAttributes(attribute.String("user.id", token.UserID.String()))
+
+	hashedPassword, err := utils.HashPassword(input.NewPassword)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "password hashing failed")
+		log.Error("password hashing failed", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not update password"})
+		return
+	}
+
+	now := time.Now()
+	consumed := false
+	err = config.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.PasswordResetToken{}).
+			Where("id = ? AND used_at IS NULL AND expires_at > ?", token.ID, now).
+			Update("used_at", now)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+		consumed = true
+
+		if err := tx.Model(&models.User{}).
+			Where("id = ?", token.UserID).
+			Update("password", hashedPassword).Error; err != nil {
+			return err
+		}
+		if err := revokeUserAccessTokens(tx, token.UserID); err != nil {
+			return err
+		}
+		return revokeUserSessions(tx, token.UserID, uuid.Nil)
+	})
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "password reset failed")
+		log.Error("password reset failed", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not update password"})
+		return
+	}
+
+	if !consumed {
+		span.AddEvent("reset_token_spent")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired reset link"})
+		return
+	}
+
+	span.SetStatus(codes.Ok, "password reset")
+	log.Info("password reset completed", zap.String("user_id", token.UserID.String()))
+	c.JSON(http.StatusOK, gin.H{"message": "Password updated successfully"})
+}
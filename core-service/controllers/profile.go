@@ -1,6 +1,7 @@
 package controllers
 
 import (
+	"context"
 	"core-service/config"
 	"core-service/models"
 	"encoding/base64"
@@ -62,9 +63,14 @@ func UpdateProfile(c *gin.Context) {
 		return
 	}
 
+	emailChanged := input.Email != "" && input.Email != user.Email
+
 	user.Username = input.Username
 	user.Email = input.Email
 	user.Avatar = input.Avatar
+	if emailChanged {
+		user.VerifiedAt = nil
+	}
 
 	if err := config.DB.WithContext(ctx).Save(&user).Error; err != nil {
 		span.RecordError(err)
@@ -79,6 +85,13 @@ func UpdateProfile(c *gin.Context) {
 		return
 	}
 
+	if emailChanged {
+		if err := sendVerificationEmail(ctx, &user); err != nil {
+			span.RecordError(err)
+			log.Error("failed to send verification email", zap.String("user_id", userID.String()), zap.Error(err))
+		}
+	}
+
 	span.SetStatus(codes.Ok, "profile updated")
 
 	log.Info("user profile updated", zap.String("user_id", userID.String()))
@@ -185,6 +198,16 @@ func GetUserTasks(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"tasks": tasks})
 }
 
+// mutualGroupIDs returns the groups both users have joined.
+func mutualGroupIDs(ctx context.Context, a, b uuid.UUID) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	err := config.DB.WithContext(ctx).Table("group_members AS a").
+		Joins("JOIN group_members AS b ON b.group_id = a.group_id").
+		Where("a.user_id = ? AND b.user_id = ? AND a.status = ? AND b.status = ?", a, b, "joined", "joined").
+		Pluck("a.group_id", &ids).Error
+	return ids, err
+}
+
 func GetMutualGroups(c *gin.Context) {
 	ctx := c.Request.Context()
 	log := logging.Logger(ctx)
@@ -192,54 +215,31 @@ func GetMutualGroups(c *gin.Context) {
 	ctx, span := materialTracer.Start(ctx, "user.mutual_groups")
 	defer span.End()
 
-	userID1 := c.MustGet("user_id").(uuid.UUID)
-	userID2 := c.Param("otherUserId")
-
+	userID := c.MustGet("user_id").(uuid.UUID)
 	span.SetAttributes(
-		attribute.String("user.id", userID1.String()),
-		attribute.String("other_user.id", userID2),
+		attribute.String("user.id", userID.String()),
+		attribute.String("other_user.id", c.Param("userId")),
 	)
 
-	var user1GroupIDs []string
-	var user2GroupIDs []string
-
-	if err := config.DB.WithContext(ctx).Model(&models.GroupMember{}).
-		Where("user_id = ? AND status = ?", userID1, "active").
-		Pluck("group_id", &user1GroupIDs).Error; err != nil {
-
-		span.RecordError(err)
-		span.SetStatus(codes.Error, "failed to fetch user groups")
-		log.Error("failed to fetch groups for user", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "database error"})
+	otherUserID, err := uuid.Parse(c.Param("userId"))
+	if err != nil {
+		span.AddEvent("invalid_user_id")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
 		return
 	}
 
-	if err := config.DB.WithContext(ctx).Model(&models.GroupMember{}).
-		Where("user_id = ? AND status = ?", userID2, "active").
-		Pluck("group_id", &user2GroupIDs).Error; err != nil {
-
+	groupIDs, err := mutualGroupIDs(ctx, userID, otherUserID)
+	if err != nil {
 		span.RecordError(err)
-		span.SetStatus(codes.Error, "failed to fetch other user groups")
-		log.Error("failed to fetch groups for other user", zap.Error(err))
+		span.SetStatus(codes.Error, "failed to fetch mutual group ids")
+		log.Error("failed to fetch mutual groups", zap.Error(err))
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "database error"})
 		return
 	}
 
-	groupMap := make(map[string]bool)
-	for _, id := range user1GroupIDs {
-		groupMap[id] = true
-	}
-
-	var mutualGroupIDs []string
-	for _, id := range user2GroupIDs {
-		if groupMap[id] {
-			mutualGroupIDs = append(mutualGroupIDs, id)
-		}
-	}
-
-	var mutualGroups []models.Group
-	if len(mutualGroupIDs) > 0 {
-		if err := config.DB.WithContext(ctx).Where("id IN ?", mutualGroupIDs).Find(&mutualGroups).Error; err != nil {
+	mutualGroups := []models.Group{}
+	if len(groupIDs) > 0 {
+		if err := config.DB.WithContext(ctx).Where("id IN ?", groupIDs).Find(&mutualGroups).Error; err != nil {
 			span.RecordError(err)
 			span.SetStatus(codes.Error, "failed to fetch mutual groups")
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "database error"})
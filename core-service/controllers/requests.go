@@ -43,19 +43,6 @@ func AcceptMemberRequest(c *gin.Context) {
 		zap.String("target_user_id", targetUserID),
 	}
 
-	var admin models.GroupMember
-	if err := config.DB.WithContext(ctx).First(
-		&admin,
-		"group_id = ? AND user_id = ? AND role = ?",
-		groupID, currentUserID, "admin",
-	).Error; err != nil {
-
-		span.AddEvent("admin_check_failed")
-		log.Warn("unauthorized attempt to accept member", auditFields...)
-		c.JSON(http.StatusForbidden, gin.H{"error": "Only admins can accept requests"})
-		return
-	}
-
 	if err := config.DB.WithContext(ctx).Model(&models.GroupMember{}).
 		Where("group_id = ? AND user_id = ? AND status = ?", groupID, targetUserID, "pending").
 		Updates(map[string]interface{}{
@@ -100,19 +87,6 @@ func RejectMemberRequest(c *gin.Context) {
 		zap.String("target_user_id", targetUserID),
 	}
 
-	var admin models.GroupMember
-	if err := config.DB.WithContext(ctx).First(
-		&admin,
-		"group_id = ? AND user_id = ? AND role = ?",
-		groupID, currentUserID, "admin",
-	).Error; err != nil {
-
-		span.AddEvent("admin_check_failed")
-		log.Warn("unauthorized attempt to reject member", auditFields...)
-		c.JSON(http.StatusForbidden, gin.H{"error": "Only admins can reject requests"})
-		return
-	}
-
 	if err := config.DB.WithContext(ctx).
 		Where("group_id = ? AND user_id = ? AND status = ?", groupID, targetUserID, "pending").
 		Delete(&models.GroupMember{}).Error; err != nil {
@@ -145,22 +119,6 @@ func GetMemberRequests(c *gin.Context) {
 		attribute.String("admin.id", currentUserID.String()),
 	)
 
-	var admin models.GroupMember
-	if err := config.DB.WithContext(ctx).First(
-		&admin,
-		"group_id = ? AND user_id = ? AND role = ?",
-		groupID, currentUserID, "admin",
-	).Error; err != nil {
-
-		span.AddEvent("admin_check_failed")
-		log.Warn("unauthorized access to member requests",
-			zap.String("group_id", groupID),
-			zap.String("user_id", currentUserID.String()),
-		)
-		c.JSON(http.StatusForbidden, gin.H{"error": "Only admins can view requests"})
-		return
-	}
-
 	parsedGroupID, err := uuid.Parse(groupID)
 	if err != nil {
 		span.AddEvent("invalid_group_id")
@@ -0,0 +1,244 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"core-service/config"
+	"core-service/internal/chat/protocol"
+	"core-service/internal/chat/rooms"
+	"core-service/internal/observability/logging"
+	"core-service/internal/permissions"
+	"core-service/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.uber.org/zap"
+	"gorm.io/gorm/clause"
+)
+
+var searchTracer = otel.Tracer("controllers.search")
+
+const (
+	searchDefaultLimit = 20
+	searchMaxLimit     = 50
+
+	// searchQuery parses the user's query like a web search box: quoted
+	// phrases, "or" and -exclusions.
+	searchQuery = "websearch_to_tsquery('" + models.SearchConfig + "', ?)"
+	// headlineOptions marks matches with <mark>; the text is HTML-escaped
+	// first so snippets are safe to render as HTML.
+	headlineOptions = "StartSel=<mark>, StopSel=</mark>, MaxFragments=2, MaxWords=20, MinWords=5"
+)
+
+// escapeHTMLSQL wraps a SQL text expression so that it is HTML-escaped.
+func escapeHTMLSQL(expr string) string {
+	return "replace(replace(replace(" + expr + ", '&', '&amp;'), '<', '&lt;'), '>', '&gt;')"
+}
+
+// parseSearchTime accepts an RFC 3339 timestamp or a plain date.
+func parseSearchTime(raw string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, raw); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.DateOnly, raw)
+}
+
+type searchHit struct {
+	MessageID uuid.UUID       `json:"message_id"`
+	Room      string          `json:"room"`
+	User      protocol.Author `json:"user"`
+	Timestamp time.Time       `json:"timestamp"`
+	Snippet   string          `json:"snippet"`
+	Files     string          `json:"file_snippet,omitempty"`
+	ParentID  *uuid.UUID      `json:"parent_id,omitempty"`
+	// HistoryBefore, passed as "before" to the room's history, returns the
+	// page that ends with this message (or, for a reply, its thread's
+	// parent). Null means the newest page already contains it.
+	HistoryBefore *uuid.UUID `json:"history_before"`
+}
+
+// SearchMessages searches the chat messages and attachment names of every
+// group and conversation the caller belongs to, best matches first.
+//
+// Query parameters: q (required), group, from, to, has=attachment, limit
+// and offset.
+func SearchMessages(c *gin.Context) {
+	ctx := c.Request.Context()
+	log := logging.Logger(ctx)
+
+	ctx, span := searchTracer.Start(ctx, "search.messages")
+	defer span.End()
+
+	userID := c.MustGet("user_id").(uuid.UUID)
+	span.SetAttributes(attribute.String("user.id", userID.String()))
+
+	text := strings.TrimSpace(c.Query("q"))
+	if text == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "q is required"})
+		return
+	}
+
+	limit, offset := searchDefaultLimit, 0
+	if raw := c.Query("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be a positive number"})
+			return
+		}
+		limit = min(n, searchMaxLimit)
+	}
+	if raw := c.Query("offset"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "offset must not be negative"})
+			return
+		}
+		offset = n
+	}
+
+	db := config.DB.WithContext(ctx)
+	q := db.Table("chat_messages AS m").
+		Select(
+			"m.id AS message_id, m.room_id, m.user_id, u.username, m.timestamp, m.parent_id, "+
+				"ts_headline('"+models.SearchConfig+"', "+escapeHTMLSQL("m.text")+", "+searchQuery+", ?) AS snippet, "+
+				"ts_headline('"+models.SearchConfig+"', "+escapeHTMLSQL("coalesce(f.names, '')")+", "+searchQuery+", ?) AS files, "+
+				"f.names IS NOT NULL AND to_tsvector('"+models.SearchConfig+"', f.names) @@ "+searchQuery+" AS file_match, "+
+				`(SELECT n.id FROM chat_messages n, chat_messages anchor
+				  WHERE anchor.id = coalesce(m.parent_id, m.id)
+				    AND n.room_id = m.room_id AND n.parent_id IS NULL
+				    AND (n.timestamp, n.id) > (anchor.timestamp, anchor.id)
+				  ORDER BY n.timestamp, n.id LIMIT 1) AS history_before`,
+			text, headlineOptions, text, headlineOptions, text,
+		).
+		Joins("JOIN users u ON u.id = m.user_id").
+		Joins("LEFT JOIN LATERAL (SELECT string_agg(a.file_name, ' ') AS names FROM attachments a WHERE a.chat_message_id = m.id) f ON true").
+		Where("m.search_vector @@ "+searchQuery, text).
+		Where("m.deleted_at IS NULL").
+		// Direct messages are only readable while the two users share a
+		// group, as in authorizeRoom.
+		Where(`m.room_id IN (
+			SELECT gm.group_id::text FROM group_members gm WHERE gm.user_id = ? AND gm.status = ?
+			UNION ALL
+			SELECT cv.room_key FROM conversations cv
+			JOIN conversation_participants cp ON cp.conversation_id = cv.id
+			WHERE cp.user_id = ? AND (cv.kind <> ? OR EXISTS (
+				SELECT 1 FROM conversation_participants other
+				JOIN group_members a ON a.user_id = cp.user_id AND a.status = ?
+				JOIN group_members b ON b.group_id = a.group_id AND b.user_id = other.user_id AND b.status = ?
+				WHERE other.conversation_id = cv.id AND other.user_id <> cp.user_id)))`,
+			userID, "joined", userID, models.ConversationDirect, "joined", "joined")
+
+	if raw := c.Query("group"); raw != "" {
+		groupID, err := uuid.Parse(raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid group ID"})
+			return
+		}
+		span.SetAttributes(attribute.String("group.id", groupID.String()))
+		room := rooms.Room{Kind: rooms.Group, GroupID: groupID}
+		if err := authorizeRoom(ctx, userID, room, permissions.ChatRead); err != nil {
+			if errors.Is(err, errRoomForbidden) {
+				c.JSON(http.StatusForbidden, gin.H{"error": "You are not a member of this group"})
+				return
+			}
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "membership check failed")
+			log.Error("failed to check group membership", zap.Error(err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Search failed"})
+			return
+		}
+		q = q.Where("m.room_id = ?", room.Key())
+	}
+	if raw := c.Query("from"); raw != "" {
+		from, err := parseSearchTime(raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "from must be a date or RFC 3339 time"})
+			return
+		}
+		q = q.Where("m.timestamp >= ?", from)
+	}
+	if raw := c.Query("to"); raw != "" {
+		to, err := parseSearchTime(raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "to must be a date or RFC 3339 time"})
+			return
+		}
+		if !strings.Contains(raw, "T") {
+			// A plain date includes the whole day.
+			to = to.AddDate(0, 0, 1)
+		}
+		q = q.Where("m.timestamp < ?", to)
+	}
+	switch has := c.Query("has"); has {
+	case "":
+	case "attachment":
+		q = q.Where("f.names IS NOT NULL")
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "has must be \"attachment\""})
+		return
+	}
+
+	var rows []struct {
+		MessageID     uuid.UUID
+		RoomID        string
+		UserID        uuid.UUID
+		Username      string
+		Timestamp     time.Time
+		ParentID      *uuid.UUID
+		Snippet       string
+		Files         string
+		FileMatch     bool
+		HistoryBefore *uuid.UUID
+	}
+	rank := clause.OrderBy{Expression: clause.Expr{
+		SQL:  "ts_rank(m.search_vector, " + searchQuery + ") DESC",
+		Vars: []interface{}{text},
+	}}
+	if err := q.Order(rank).
+		Order("m.timestamp desc").
+		Limit(limit + 1).
+		Offset(offset).
+		Scan(&rows).Error; err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "search query failed")
+		log.Error("message search failed", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Search failed"})
+		return
+	}
+
+	var nextOffset *int
+	if len(rows) > limit {
+		rows = rows[:limit]
+		n := offset + limit
+		nextOffset = &n
+	}
+
+	hits := make([]searchHit, 0, len(rows))
+	for _, r := range rows {
+		room, _ := rooms.FromKey(r.RoomID)
+		hit := searchHit{
+			MessageID:     r.MessageID,
+			Room:          room.String(),
+			User:          protocol.Author{ID: r.UserID.String(), Name: r.Username},
+			Timestamp:     r.Timestamp,
+			Snippet:       r.Snippet,
+			ParentID:      r.ParentID,
+			HistoryBefore: r.HistoryBefore,
+		}
+		if r.FileMatch {
+			hit.Files = r.Files
+		}
+		hits = append(hits, hit)
+	}
+
+	span.SetAttributes(attribute.Int("results.count", len(hits)))
+	span.SetStatus(codes.Ok, "search complete")
+	c.JSON(http.StatusOK, gin.H{"results": hits, "next_offset": nextOffset})
+}
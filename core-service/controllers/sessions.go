@@ -0,0 +1,357 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"core-service/config"
+	"core-service/models"
+	"core-service/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"core-service/internal/observability/logging"
+	"core-service/internal/observability/metrics"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+)
+
+var sessionTracer = otel.Tracer("controllers.session")
+
+const (
+	accessCookie  = "token"
+	refreshCookie = "refresh_token"
+	// refreshCookiePath limits the refresh token to the auth endpoints that
+	// consume it, so it is not sent along with every API request.
+	refreshCookiePath = "/auth"
+)
+
+// startSession records a new session for the user and sets the access and
+// refresh cookies on the response.
+func startSession(c *gin.Context, ctx context.Context, userID uuid.UUID) (*models.Session, error) {
+	now := time.Now()
+	session := models.Session{
+		UserID:     userID,
+		UserAgent:  c.Request.UserAgent(),
+		IPAddress:  c.ClientIP(),
+		CreatedAt:  now,
+		LastSeenAt: now,
+		ExpiresAt:  now.Add(utils.RefreshTokenTTL),
+	}
+
+	var refresh string
+	err := config.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&session).Error; err != nil {
+			return err
+		}
+		var err error
+		refresh, _, err = createRefreshToken(tx, &session, now)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := setSessionCookies(c, &session, refresh); err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// createRefreshToken adds a new token to the session's family and returns
+// its plaintext value together with the stored row.
+func createRefreshToken(tx *gorm.DB, session *models.Session, now time.Time) (string, *models.RefreshToken, error) {
+	plain, err := utils.GenerateOpaqueToken()
+	if err != nil {
+		return "", nil, err
+	}
+
+	expiresAt := now.Add(utils.RefreshTokenTTL)
+	if limit := session.CreatedAt.Add(utils.SessionMaxLifetime); expiresAt.After(limit) {
+		expiresAt = limit
+	}
+
+	token := models.RefreshToken{
+		SessionID: session.ID,
+		TokenHash: utils.HashToken(plain),
+		CreatedAt: now,
+		ExpiresAt: expiresAt,
+	}
+	if err := tx.Create(&token).Error; err != nil {
+		return "", nil, err
+	}
+	if err := tx.Model(session).Update("expires_at", expiresAt).Error; err != nil {
+		return "", nil, err
+	}
+	return plain, &token, nil
+}
+
+func setSessionCookies(c *gin.Context, session *models.Session, refresh string) error {
+	access, err := utils.GenerateToken(session.UserID, session.ID)
+	if err != nil {
+		return err
+	}
+
+	c.SetCookie(accessCookie, access, int(utils.AccessTokenTTL.Seconds()), "/", "localhost", false, true)
+	c.SetCookie(refreshCookie, refresh, int(time.Until(session.ExpiresAt).Seconds()), refreshCookiePath, "localhost", false, true)
+	return nil
+}
+
+func clearSessionCookie(c *gin.Context) {
+	c.SetCookie(accessCookie, "", -1, "/", "localhost", false, true)
+	c.SetCookie(refreshCookie, "", -1, refreshCookiePath, "localhost", false, true)
+}
+
+// revokeUserSessions revokes every active session of the user except keep,
+// which may be uuid.Nil to revoke them all.
+func revokeUserSessions(tx *gorm.DB, userID uuid.UUID, keep uuid.UUID) error {
+	q := tx.Model(&models.Session{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID)
+	if keep != uuid.Nil {
+		q = q.Where("id <> ?", keep)
+	}
+	return q.Update("revoked_at", time.Now()).Error
+}
+
+// RefreshSession rotates the caller's refresh token and issues a new access
+// token. A refresh token can be used exactly once; presenting it again is
+// treated as theft and revokes the whole session.
+func RefreshSession(c *gin.Context) {
+	ctx := c.Request.Context()
+	log := logging.Logger(ctx)
+
+	ctx, span := sessionTracer.Start(ctx, "session.refresh")
+	defer span.End()
+
+	plain, err := c.Cookie(refreshCookie)
+	if err != nil || plain == "" {
+		span.AddEvent("missing_refresh_token")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "missing refresh token"})
+		return
+	}
+
+	var current models.RefreshToken
+	if err := config.DB.WithContext(ctx).
+		First(&current, "token_hash = ?", utils.HashToken(plain)).Error; err != nil {
+
+		span.AddEvent("unknown_refresh_token")
+		log.Warn("unknown refresh token presented")
+		clearSessionCookie(c)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid refresh token"})
+		return
+	}
+
+	span.SetAttributes(attribute.String("session.id", current.SessionID.String()))
+
+	var session models.Session
+	if err := config.DB.WithContext(ctx).First(&session, "id = ?", current.SessionID).Error; err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "session lookup failed")
+		clearSessionCookie(c)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid refresh token"})
+		return
+	}
+
+	now := time.Now()
+	if current.UsedAt != nil {
+		revokeRefreshFamily(c, ctx, &session, now)
+		return
+	}
+
+	if !session.Active(now) || now.After(current.ExpiresAt) {
+		span.AddEvent("session_expired")
+		clearSessionCookie(c)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "session expired"})
+		return
+	}
+
+	var refresh string
+	reused := false
+	err = config.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var next *models.RefreshToken
+		var err error
+		refresh, next, err = createRefreshToken(tx, &session, now)
+		if err != nil {
+			return err
+		}
+
+		// The used_at guard makes two concurrent refreshes with the same
+		// token resolve to exactly one winner.
+		result := tx.Model(&models.RefreshToken{}).
+			Where("id = ? AND used_at IS NULL", current.ID).
+			Updates(map[string]interface{}{"used_at": now, "replaced_by_id": next.ID})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			reused = true
+			return gorm.ErrRecordNotFound
+		}
+		return tx.Model(&session).UpdateColumn("last_seen_at", now).Error
+	})
+
+	if reused {
+		revokeRefreshFamily(c, ctx, &session, now)
+		return
+	}
+	if err == nil {
+		err = setSessionCookies(c, &session, refresh)
+	}
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "token rotation failed")
+		log.Error("refresh token rotation failed", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to refresh session"})
+		return
+	}
+
+	span.SetStatus(codes.Ok, "session refreshed")
+	c.JSON(http.StatusOK, gin.H{"message": "Session refreshed"})
+}
+
+// revokeRefreshFamily handles a replayed refresh token by revoking the
+// session it belongs to, which invalidates every token of the family.
+func revokeRefreshFamily(c *gin.Context, ctx context.Context, session *models.Session, now time.Time) {
+	log := logging.Logger(ctx)
+
+	metrics.AuthRefreshReuse.Add(ctx, 1)
+	log.Warn(
+		"refresh token reuse detected, revoking session",
+		zap.String("user_id", session.UserID.String()),
+		zap.String("session_id", session.ID.String()),
+	)
+
+	if err := config.DB.WithContext(ctx).Model(session).
+		Where("revoked_at IS NULL").
+		Update("revoked_at", now).Error; err != nil {
+		log.Error("failed to revoke session after token reuse", zap.Error(err))
+	}
+
+	clearSessionCookie(c)
+	c.JSON(http.StatusUnauthorized, gin.H{"error": "refresh token reuse detected"})
+}
+
+func ListSessions(c *gin.Context) {
+	ctx := c.Request.Context()
+	log := logging.Logger(ctx)
+
+	ctx, span := sessionTracer.Start(ctx, "session.list")
+	defer span.End()
+
+	userID := c.MustGet("user_id").(uuid.UUID)
+	currentID := c.MustGet("session_id").(uuid.UUID)
+	span.SetAttributes(attribute.String("user.id", userID.String()))
+
+	var sessions []models.Session
+	if err := config.DB.WithContext(ctx).
+		Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, time.Now()).
+		Order("last_seen_at desc").
+		Find(&sessions).Error; err != nil {
+
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to list sessions")
+		log.Error("failed to list sessions", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch sessions"})
+		return
+	}
+
+	type SessionInfo struct {
+		models.Session
+		Current bool `json:"current"`
+	}
+
+	result := make([]SessionInfo, 0, len(sessions))
+	for _, s := range sessions {
+		result = append(result, SessionInfo{Session: s, Current: s.ID == currentID})
+	}
+
+	span.SetAttributes(attribute.Int("sessions.count", len(result)))
+	span.SetStatus(codes.Ok, "sessions listed")
+
+	c.JSON(http.StatusOK, gin.H{"sessions": result})
+}
+
+func RevokeSession(c *gin.Context) {
+	ctx := c.Request.Context()
+	log := logging.Logger(ctx)
+
+	ctx, span := sessionTracer.Start(ctx, "session.revoke")
+	defer span.End()
+
+	userID := c.MustGet("user_id").(uuid.UUID)
+	currentID := c.MustGet("session_id").(uuid.UUID)
+
+	sessionIDStr := c.Param("sessionId")
+	sessionID, err := uuid.Parse(sessionIDStr)
+	if err != nil {
+		span.AddEvent("invalid_session_id")
+		log.Warn("invalid session_id", zap.String("session_id", sessionIDStr))
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid session ID"})
+		return
+	}
+
+	span.SetAttributes(
+		attribute.String("user.id", userID.String()),
+		attribute.String("session.id", sessionID.String()),
+	)
+
+	result := config.DB.WithContext(ctx).Model(&models.Session{}).
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL", sessionID, userID).
+		Update("revoked_at", time.Now())
+
+	if result.Error != nil {
+		span.RecordError(result.Error)
+		span.SetStatus(codes.Error, "session revoke failed")
+		log.Error("failed to revoke session", zap.Error(result.Error))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke session"})
+		return
+	}
+
+	if result.RowsAffected == 0 {
+		span.AddEvent("session_not_found")
+		c.JSON(http.StatusNotFound, gin.H{"error": "Session not found"})
+		return
+	}
+
+	if sessionID == currentID {
+		clearSessionCookie(c)
+	}
+
+	span.SetStatus(codes.Ok, "session revoked")
+	log.Info(
+		"session revoked",
+		zap.String("user_id", userID.String()),
+		zap.String("session_id", sessionID.String()),
+	)
+	c.JSON(http.StatusOK, gin.H{"message": "Session revoked"})
+}
+
+func RevokeOtherSessions(c *gin.Context) {
+	ctx := c.Request.Context()
+	log := logging.Logger(ctx)
+
+	ctx, span := sessionTracer.Start(ctx, "session.revoke_others")
+	defer span.End()
+
+	userID := c.MustGet("user_id").(uuid.UUID)
+	currentID := c.MustGet("session_id").(uuid.UUID)
+	span.SetAttributes(attribute.String("user.id", userID.String()))
+
+	if err := revokeUserSessions(config.DB.WithContext(ctx), userID, currentID); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "session revoke failed")
+		log.Error("failed to revoke sessions", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke sessions"})
+		return
+	}
+
+	span.SetStatus(codes.Ok, "sessions revoked")
+	log.Info("other sessions revoked", zap.String("user_id", userID.String()))
+	c.JSON(http.StatusOK, gin.H{"message": "Other sessions revoked"})
+}
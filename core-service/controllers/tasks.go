@@ -1,6 +1,7 @@
 package controllers
 
 import (
+	"context"
 	"net/http"
 	"time"
 
@@ -20,6 +21,39 @@ import (
 
 var taskTracer = otel.Tracer("controllers.task")
 
+// createTask stores a new task for the group, assigned by userID. It is
+// shared by the REST API and the /task chat command.
+func createTask(ctx context.Context, groupID, userID uuid.UUID, title, description, status string, deadline time.Time) (*models.Task, error) {
+	now := time.Now()
+	task := &models.Task{
+		ID:          uuid.New(),
+		GroupID:     groupID,
+		Title:       title,
+		Description: description,
+		Status:      status,
+		Deadline:    deadline,
+		AssignedBy:  userID.String(),
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if err := config.DB.WithContext(ctx).Create(task).Error; err != nil {
+		return task, err
+	}
+	return task, nil
+}
+
+// upcomingTasks returns the group's unfinished tasks that are not yet
+// overdue, soonest first.
+func upcomingTasks(ctx context.Context, groupID uuid.UUID, limit int) ([]models.Task, error) {
+	var tasks []models.Task
+	err := config.DB.WithContext(ctx).
+		Where("group_id = ? AND deadline >= ? AND status <> ?", groupID, time.Now(), "completed").
+		Order("deadline asc").
+		Limit(limit).
+		Find(&tasks).Error
+	return tasks, err
+}
+
 func CreateTask(c *gin.Context) {
 	ctx := c.Request.Context()
 	log := logging.Logger(ctx)
@@ -66,21 +100,8 @@ func CreateTask(c *gin.Context) {
 		return
 	}
 
-	task := models.Task{
-		ID:          uuid.New(),
-		GroupID:     parsedGroupID,
-		Title:       body.Title,
-		Description: body.Description,
-		Status:      body.Status,
-		Deadline:    deadline,
-		AssignedBy:  userId.String(),
-		CreatedAt:   time.Now(),
-		UpdatedAt:   time.Now(),
-	}
-
-	span.SetAttributes(attribute.String("task.id", task.ID.String()))
-
-	if err := config.DB.WithContext(ctx).Create(&task).Error; err != nil {
+	task, err := createTask(ctx, parsedGroupID, userId, body.Title, body.Description, body.Status, deadline)
+	if err != nil {
 		span.RecordError(err)
 		span.SetStatus(codes.Error, "task creation failed")
 
@@ -93,6 +114,8 @@ func CreateTask(c *gin.Context) {
 		return
 	}
 
+	span.SetAttributes(attribute.String("task.id", task.ID.String()))
+
 	span.SetStatus(codes.Ok, "task created")
 
 	log.Info(
@@ -0,0 +1,152 @@
+// Package lockout throttles repeated authentication failures. Each key (an
+// account, a client IP) accumulates failures; past a few free attempts every
+// further failure doubles the wait before the next try, and enough of them
+// lock the key for a fixed period.
+package lockout
+
+import (
+	"context"
+	"time"
+)
+
+// Entry is the failure state of one key.
+type Entry struct {
+	Failures     int
+	LastFailure  time.Time
+	BlockedUntil time.Time
+	// ExpiresAt is when the entry no longer matters and may be dropped.
+	ExpiresAt time.Time
+}
+
+// Store persists entries. Update must apply fn atomically per key so that
+// concurrent failures are all counted.
+type Store interface {
+	Get(ctx context.Context, key string) (Entry, error)
+	Update(ctx context.Context, key string, fn func(Entry) Entry) (Entry, error)
+	Delete(ctx context.Context, key string) error
+	// Close stops any background work the store runs.
+	Close() error
+}
+
+// Policy controls how quickly a key is slowed down and locked.
+type Policy struct {
+	// FreeAttempts failures are allowed without any delay.
+	FreeAttempts int
+	// BaseDelay is the wait after the first failure past FreeAttempts; it
+	// doubles with every further failure up to MaxDelay.
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+	// LockThreshold failures lock the key for LockDuration.
+	LockThreshold int
+	LockDuration  time.Duration
+	// Window is how long after the last failure the count is forgotten.
+	Window time.Duration
+}
+
+// AccountPolicy applies to a single account.
+var AccountPolicy = Policy{
+	FreeAttempts:  3,
+	BaseDelay:     time.Second,
+	MaxDelay:      time.Minute,
+	LockThreshold: 10,
+	LockDuration:  15 * time.Minute,
+	Window:        time.Hour,
+}
+
+// IPPolicy applies to a client address, which may be shared by many users
+// behind one NAT, so it is more lenient.
+var IPPolicy = Policy{
+	FreeAttempts:  20,
+	BaseDelay:     time.Second,
+	MaxDelay:      time.Minute,
+	LockThreshold: 100,
+	LockDuration:  time.Hour,
+	Window:        time.Hour,
+}
+
+// Result describes a key after a recorded failure.
+type Result struct {
+	Failures   int
+	RetryAfter time.Duration
+	// Locked is true only for the failure that crossed LockThreshold, so
+	// callers can notify once per lock.
+	Locked bool
+}
+
+type Limiter struct {
+	store  Store
+	policy Policy
+	now    func() time.Time
+}
+
+func NewLimiter(store Store, policy Policy) *Limiter {
+	return &Limiter{store: store, policy: policy, now: time.Now}
+}
+
+// RetryAfter returns how long the key must wait before its next attempt, or
+// zero when it may try now.
+func (l *Limiter) RetryAfter(ctx context.Context, key string) (time.Duration, error) {
+	e, err := l.store.Get(ctx, key)
+	if err != nil {
+		return 0, err
+	}
+	if wait := e.BlockedUntil.Sub(l.now()); wait > 0 {
+		return wait, nil
+	}
+	return 0, nil
+}
+
+// Failure records a failed attempt for the key.
+func (l *Limiter) Failure(ctx context.Context, key string) (Result, error) {
+	now := l.now()
+	var res Result
+
+	_, err := l.store.Update(ctx, key, func(e Entry) Entry {
+		if !e.LastFailure.IsZero() && now.Sub(e.LastFailure) > l.policy.Window && now.After(e.BlockedUntil) {
+			e = Entry{}
+		}
+		e.Failures++
+		e.LastFailure = now
+
+		res = Result{Failures: e.Failures}
+		switch {
+		case e.Failures == l.policy.LockThreshold:
+			e.BlockedUntil = now.Add(l.policy.LockDuration)
+			res.Locked = true
+		case e.Failures > l.policy.LockThreshold:
+			// Failures while locked can only happen once the lock ran out;
+			// each one re-locks.
+			e.BlockedUntil = now.Add(l.policy.LockDuration)
+		case e.Failures > l.policy.FreeAttempts:
+			e.BlockedUntil = now.Add(l.policy.delay(e.Failures - l.policy.FreeAttempts))
+		}
+		res.RetryAfter = e.BlockedUntil.Sub(now)
+		if res.RetryAfter < 0 {
+			res.RetryAfter = 0
+		}
+
+		e.ExpiresAt = now.Add(l.policy.Window)
+		if e.BlockedUntil.After(e.ExpiresAt) {
+			e.ExpiresAt = e.BlockedUntil
+		}
+		return e
+	})
+	return res, err
+}
+
+// Reset forgets the key's failures, after a successful login or when an
+// admin unlocks it.
+func (l *Limiter) Reset(ctx context.Context, key string) error {
+	return l.store.Delete(ctx, key)
+}
+
+func (p Policy) delay(n int) time.Duration {
+	d := p.BaseDelay
+	for i := 1; i < n; i++ {
+		d *= 2
+		if d >= p.MaxDelay {
+			return p.MaxDelay
+		}
+	}
+	return d
+}
@@ -0,0 +1,98 @@
+package lockout
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func newTestLimiter(p Policy) (*Limiter, *time.Time) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	l := NewLimiter(NewMemoryStore(), p)
+	l.now = func() time.Time { return now }
+	return l, &now
+}
+
+var testPolicy = Policy{
+	FreeAttempts:  2,
+	BaseDelay:     time.Second,
+	MaxDelay:      8 * time.Second,
+	LockThreshold: 8,
+	LockDuration:  20 * time.Minute,
+	Window:        30 * time.Minute,
+}
+
+func TestLimiter_BackoffDoublesAndCaps(t *testing.T) {
+	l, _ := newTestLimiter(testPolicy)
+	ctx := context.Background()
+
+	want := []time.Duration{0, 0, time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second, 8 * time.Second}
+	for i, w := range want {
+		res, err := l.Failure(ctx, "account:a")
+		if err != nil {
+			t.Fatalf("Failure: %v", err)
+		}
+		if res.RetryAfter != w || res.Locked {
+			t.Errorf("failure %d: got retry %v locked %v, want %v", i+1, res.RetryAfter, res.Locked, w)
+		}
+	}
+
+	wait, _ := l.RetryAfter(ctx, "account:a")
+	if wait != 8*time.Second {
+		t.Errorf("RetryAfter = %v, want 8s", wait)
+	}
+	if wait, _ := l.RetryAfter(ctx, "account:b"); wait != 0 {
+		t.Errorf("unrelated key is blocked for %v", wait)
+	}
+}
+
+func TestLimiter_LocksOnceAtThreshold(t *testing.T) {
+	l, now := newTestLimiter(testPolicy)
+	ctx := context.Background()
+
+	var locks int
+	for i := 0; i < testPolicy.LockThreshold; i++ {
+		res, _ := l.Failure(ctx, "k")
+		if res.Locked {
+			locks++
+		}
+	}
+	if locks != 1 {
+		t.Fatalf("expected exactly one lock event, got %d", locks)
+	}
+	if wait, _ := l.RetryAfter(ctx, "k"); wait != testPolicy.LockDuration {
+		t.Errorf("RetryAfter = %v, want %v", wait, testPolicy.LockDuration)
+	}
+
+	// Within the window a failure after the lock ran out locks again.
+	*now = now.Add(testPolicy.LockDuration + time.Second)
+	if wait, _ := l.RetryAfter(ctx, "k"); wait != 0 {
+		t.Errorf("still blocked after lock expired: %v", wait)
+	}
+	if res, _ := l.Failure(ctx, "k"); res.Locked || res.RetryAfter != testPolicy.LockDuration {
+		t.Errorf("failure after lock should re-lock silently, got %+v", res)
+	}
+}
+
+func TestLimiter_WindowAndReset(t *testing.T) {
+	l, now := newTestLimiter(testPolicy)
+	ctx := context.Background()
+
+	for i := 0; i < 4; i++ {
+		l.Failure(ctx, "k")
+	}
+
+	*now = now.Add(testPolicy.Window + time.Minute)
+	if res, _ := l.Failure(ctx, "k"); res.Failures != 1 {
+		t.Errorf("failures not forgotten after window: %d", res.Failures)
+	}
+
+	l.Failure(ctx, "k")
+	l.Failure(ctx, "k")
+	if err := l.Reset(ctx, "k"); err != nil {
+		t.Fatalf("Reset: %v", err)
+	}
+	if wait, _ := l.RetryAfter(ctx, "k"); wait != 0 {
+		t.Errorf("blocked after reset: %v", wait)
+	}
+}
@@ -0,0 +1,138 @@
+package lockout
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// pruneInterval is how often PostgresStore deletes expired entries.
+const pruneInterval = time.Hour
+
+// throttleRow is the table behind PostgresStore.
+type throttleRow struct {
+	Key          string `gorm:"primaryKey;type:varchar(320)"`
+	Failures     int    `gorm:"not null"`
+	LastFailure  time.Time
+	BlockedUntil time.Time
+	ExpiresAt    time.Time `gorm:"index"`
+}
+
+func (throttleRow) TableName() string {
+	return "login_throttles"
+}
+
+// PostgresStore shares entries between instances through the database.
+type PostgresStore struct {
+	db  *gorm.DB
+	log *zap.Logger
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewPostgresStore migrates the throttle table and returns the store. It
+// prunes expired entries every pruneInterval until Close.
+func NewPostgresStore(db *gorm.DB, log *zap.Logger) (*PostgresStore, error) {
+	if err := db.AutoMigrate(&throttleRow{}); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s := &PostgresStore{
+		db:     db,
+		log:    log.With(zap.String("component", "auth.lockout")),
+		ctx:    ctx,
+		cancel: cancel,
+	}
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		ticker := time.NewTicker(pruneInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case now := <-ticker.C:
+				if _, err := s.Prune(ctx, now); err != nil && ctx.Err() == nil {
+					s.log.Warn("failed to prune login throttles", zap.Error(err))
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return s, nil
+}
+
+func (s *PostgresStore) Get(ctx context.Context, key string) (Entry, error) {
+	var row throttleRow
+	err := s.db.WithContext(ctx).First(&row, "key = ?", key).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return Entry{}, nil
+	}
+	if err != nil {
+		return Entry{}, err
+	}
+	return row.entry(), nil
+}
+
+func (s *PostgresStore) Update(ctx context.Context, key string, fn func(Entry) Entry) (Entry, error) {
+	var out Entry
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Make sure the row exists so it can be locked; concurrent first
+		// failures then serialise on the row lock instead of racing.
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).
+			Create(&throttleRow{Key: key}).Error; err != nil {
+			return err
+		}
+
+		var row throttleRow
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			First(&row, "key = ?", key).Error; err != nil {
+			return err
+		}
+
+		out = fn(row.entry())
+		return tx.Model(&throttleRow{}).Where("key = ?", key).Updates(map[string]interface{}{
+			"failures":      out.Failures,
+			"last_failure":  out.LastFailure,
+			"blocked_until": out.BlockedUntil,
+			"expires_at":    out.ExpiresAt,
+		}).Error
+	})
+	return out, err
+}
+
+func (s *PostgresStore) Delete(ctx context.Context, key string) error {
+	return s.db.WithContext(ctx).Delete(&throttleRow{}, "key = ?", key).Error
+}
+
+// Prune deletes entries that have expired.
+func (s *PostgresStore) Prune(ctx context.Context, now time.Time) (int64, error) {
+	result := s.db.WithContext(ctx).Where("expires_at < ?", now).Delete(&throttleRow{})
+	return result.RowsAffected, result.Error
+}
+
+// Close stops pruning.
+func (s *PostgresStore) Close() error {
+	s.cancel()
+	s.wg.Wait()
+	return nil
+}
+
+func (r throttleRow) entry() Entry {
+	return Entry{
+		Failures:     r.Failures,
+		LastFailure:  r.LastFailure,
+		BlockedUntil: r.BlockedUntil,
+		ExpiresAt:    r.ExpiresAt,
+	}
+}
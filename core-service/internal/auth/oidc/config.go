@@ -0,0 +1,56 @@
+package oidc
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Config describes one OpenID Connect provider.
+type Config struct {
+	Name         string
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+}
+
+// LoadConfigs reads the providers listed in OIDC_PROVIDERS (comma separated
+// names). Each name N is configured through OIDC_<N>_ISSUER,
+// OIDC_<N>_CLIENT_ID, OIDC_<N>_CLIENT_SECRET, OIDC_<N>_REDIRECT_URL and
+// OIDC_<N>_SCOPES.
+func LoadConfigs(publicURL string) ([]Config, error) {
+	var configs []Config
+
+	for _, name := range strings.Split(os.Getenv("OIDC_PROVIDERS"), ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+
+		prefix := "OIDC_" + strings.ToUpper(strings.ReplaceAll(name, "-", "_")) + "_"
+		cfg := Config{
+			Name:         name,
+			Issuer:       strings.TrimSuffix(os.Getenv(prefix+"ISSUER"), "/"),
+			ClientID:     os.Getenv(prefix + "CLIENT_ID"),
+			ClientSecret: os.Getenv(prefix + "CLIENT_SECRET"),
+			RedirectURL:  os.Getenv(prefix + "REDIRECT_URL"),
+			Scopes:       strings.Fields(os.Getenv(prefix + "SCOPES")),
+		}
+
+		if cfg.Issuer == "" || cfg.ClientID == "" {
+			return nil, fmt.Errorf("oidc provider %q needs %sISSUER and %sCLIENT_ID", name, prefix, prefix)
+		}
+		if cfg.RedirectURL == "" {
+			cfg.RedirectURL = strings.TrimSuffix(publicURL, "/") + "/auth/oidc/" + name + "/callback"
+		}
+		if len(cfg.Scopes) == 0 {
+			cfg.Scopes = []string{"openid", "email", "profile"}
+		}
+
+		configs = append(configs, cfg)
+	}
+
+	return configs, nil
+}
@@ -0,0 +1,148 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func TestCodeChallenge_RFC7636(t *testing.T) {
+	got := CodeChallenge("dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk")
+	if got != "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM" {
+		t.Errorf("unexpected challenge %s", got)
+	}
+}
+
+// fakeIssuer is a minimal provider: discovery, JWKS and a token endpoint
+// that checks the PKCE verifier before handing out an ID token.
+type fakeIssuer struct {
+	*httptest.Server
+	key       *rsa.PrivateKey
+	challenge string
+	nonce     string
+}
+
+func newFakeIssuer(t *testing.T) *fakeIssuer {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	f := &fakeIssuer{key: key}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 f.URL,
+			"authorization_endpoint": f.URL + "/authorize",
+			"token_endpoint":         f.URL + "/token",
+			"jwks_uri":               f.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"keys": []map[string]string{{
+				"kty": "RSA",
+				"kid": "k1",
+				"use": "sig",
+				"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			}},
+		})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		if CodeChallenge(r.PostForm.Get("code_verifier")) != f.challenge {
+			http.Error(w, `{"error":"invalid_grant"}`, http.StatusBadRequest)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{
+			"access_token": "at",
+			"token_type":   "Bearer",
+			"id_token":     f.idToken(t, "client-1"),
+		})
+	})
+
+	f.Server = httptest.NewServer(mux)
+	t.Cleanup(f.Close)
+	return f
+}
+
+func (f *fakeIssuer) idToken(t *testing.T, audience string) string {
+	claims := jwt.MapClaims{
+		"iss":            f.URL,
+		"sub":            "user-42",
+		"aud":            audience,
+		"exp":            time.Now().Add(time.Minute).Unix(),
+		"nonce":          f.nonce,
+		"email":          "ada@uni.example",
+		"email_verified": "true",
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = "k1"
+	signed, err := token.SignedString(f.key)
+	if err != nil {
+		t.Fatalf("sign id token: %v", err)
+	}
+	return signed
+}
+
+func TestProvider_AuthorizationCodeFlow(t *testing.T) {
+	issuer := newFakeIssuer(t)
+	p := NewProvider(Config{
+		Name:        "test",
+		Issuer:      issuer.URL,
+		ClientID:    "client-1",
+		RedirectURL: "http://localhost:8080/auth/oidc/test/callback",
+		Scopes:      []string{"openid", "email"},
+	}, nil)
+
+	ctx := context.Background()
+	verifier, _ := RandomString()
+	issuer.nonce = "n-1"
+
+	authURL, err := p.AuthCodeURL(ctx, "s-1", issuer.nonce, verifier)
+	if err != nil {
+		t.Fatalf("AuthCodeURL failed: %v", err)
+	}
+	u, _ := url.Parse(authURL)
+	issuer.challenge = u.Query().Get("code_challenge")
+	if u.Query().Get("code_challenge_method") != "S256" || !strings.HasSuffix(u.Path, "/authorize") {
+		t.Fatalf("unexpected authorization URL %s", authURL)
+	}
+
+	tokens, err := p.Exchange(ctx, "code-1", verifier)
+	if err != nil {
+		t.Fatalf("Exchange failed: %v", err)
+	}
+
+	claims, err := p.VerifyIDToken(ctx, tokens.IDToken, issuer.nonce)
+	if err != nil {
+		t.Fatalf("VerifyIDToken failed: %v", err)
+	}
+	if claims.Subject != "user-42" || claims.Email != "ada@uni.example" || !bool(claims.EmailVerified) {
+		t.Errorf("unexpected claims %+v", claims)
+	}
+
+	if _, err := p.VerifyIDToken(ctx, tokens.IDToken, "other-nonce"); err == nil {
+		t.Error("expected nonce mismatch to fail")
+	}
+	if _, err := p.VerifyIDToken(ctx, issuer.idToken(t, "someone-else"), issuer.nonce); err == nil {
+		t.Error("expected foreign audience to fail")
+	}
+	if _, err := p.Exchange(ctx, "code-1", "wrong-verifier"); err == nil {
+		t.Error("expected wrong PKCE verifier to fail")
+	}
+}
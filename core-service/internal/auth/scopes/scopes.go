@@ -0,0 +1,100 @@
+// Package scopes defines what a personal access token may be granted and
+// which routes each grant opens up. Routes that are not listed here are
+// only reachable with a browser session.
+package scopes
+
+import (
+	"fmt"
+	"sort"
+)
+
+const (
+	ProfileRead = "profile:read"
+	GroupsRead  = "groups:read"
+	GroupsWrite = "groups:write"
+	TasksRead   = "tasks:read"
+	TasksWrite  = "tasks:write"
+)
+
+var known = map[string]string{
+	ProfileRead: "Read your profile and public profiles",
+	GroupsRead:  "List your groups, members and join requests",
+	GroupsWrite: "Create, join and manage groups",
+	TasksRead:   "Read group and personal tasks",
+	TasksWrite:  "Create tasks",
+}
+
+// routes maps "METHOD /full/path" as reported by gin to the scope a token
+// needs for it.
+var routes = map[string]string{
+	"GET /user/me":                             ProfileRead,
+	"GET /user/:userId/profile":                ProfileRead,
+	"GET /user/groups":                         GroupsRead,
+	"GET /user/users/:userId/mutual-groups":    GroupsRead,
+	"GET /user/tasks":                          TasksRead,
+	"GET /user/:userId/tasks/urgent":           TasksRead,
+	"GET /groups/:groupId":                     GroupsRead,
+	"GET /groups/:groupId/members":             GroupsRead,
+	"GET /groups/:groupId/user/status":         GroupsRead,
+	"GET /groups/:groupId/requests":            GroupsRead,
+	"POST /groups":                             GroupsWrite,
+	"POST /groups/:groupId/join":               GroupsWrite,
+	"PUT /groups/:groupId":                     GroupsWrite,
+	"PUT /groups/:groupId/requests/:userId":    GroupsWrite,
+	"DELETE /groups/:groupId/requests/:userId": GroupsWrite,
+	"PUT /groups/:groupId/members/:memberid":   GroupsWrite,
+	"GET /groups/:groupId/tasks":               TasksRead,
+	"POST /groups/:groupId/tasks":              TasksWrite,
+}
+
+// Known returns every grantable scope with its description.
+func Known() map[string]string {
+	out := make(map[string]string, len(known))
+	for k, v := range known {
+		out[k] = v
+	}
+	return out
+}
+
+// Validate checks a requested scope list and returns it sorted and
+// de-duplicated.
+func Validate(requested []string) ([]string, error) {
+	if len(requested) == 0 {
+		return nil, fmt.Errorf("at least one scope is required")
+	}
+
+	seen := make(map[string]bool, len(requested))
+	var out []string
+	for _, s := range requested {
+		if _, ok := known[s]; !ok {
+			return nil, fmt.Errorf("unknown scope %q", s)
+		}
+		if !seen[s] {
+			seen[s] = true
+			out = append(out, s)
+		}
+	}
+	sort.Strings(out)
+	return out, nil
+}
+
+// Required returns the scope needed for a route, and false when the route
+// is not available to tokens at all.
+func Required(method, fullPath string) (string, bool) {
+	s, ok := routes[method+" "+fullPath]
+	return s, ok
+}
+
+// Allows reports whether a token holding granted may call the route.
+func Allows(granted []string, method, fullPath string) bool {
+	need, ok := Required(method, fullPath)
+	if !ok {
+		return false
+	}
+	for _, s := range granted {
+		if s == need {
+			return true
+		}
+	}
+	return false
+}
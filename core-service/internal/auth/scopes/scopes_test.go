@@ -0,0 +1,45 @@
+package scopes
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	got, err := Validate([]string{TasksWrite, GroupsRead, TasksWrite})
+	if err != nil {
+		t.Fatalf("Validate failed: %v", err)
+	}
+	if want := []string{GroupsRead, TasksWrite}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	if _, err := Validate([]string{"admin:all"}); err == nil {
+		t.Error("expected unknown scope to be rejected")
+	}
+	if _, err := Validate(nil); err == nil {
+		t.Error("expected empty scope list to be rejected")
+	}
+}
+
+func TestAllows(t *testing.T) {
+	granted := []string{TasksWrite}
+
+	if !Allows(granted, "POST", "/groups/:groupId/tasks") {
+		t.Error("tasks:write should allow creating tasks")
+	}
+	if Allows(granted, "GET", "/groups/:groupId/tasks") {
+		t.Error("tasks:write should not imply tasks:read")
+	}
+	if Allows([]string{ProfileRead, GroupsWrite, TasksWrite}, "POST", "/user/me/tokens") {
+		t.Error("unlisted routes must be denied to tokens")
+	}
+}
+
+func TestRoutesUseKnownScopes(t *testing.T) {
+	for route, s := range routes {
+		if _, ok := known[s]; !ok {
+			t.Errorf("route %s maps to unknown scope %s", route, s)
+		}
+	}
+}
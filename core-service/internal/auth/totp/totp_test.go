@@ -0,0 +1,65 @@
+package totp
+
+import (
+	"encoding/base32"
+	"strings"
+	"testing"
+	"time"
+)
+
+// rfcSecret is the SHA1 seed from RFC 6238 appendix B.
+var rfcSecret = base32.StdEncoding.WithPadding(base32.NoPadding).
+	EncodeToString([]byte("12345678901234567890"))
+
+func TestCodeAt_RFC6238Vectors(t *testing.T) {
+	// The RFC lists 8-digit codes; the 6-digit code is their last 6 digits.
+	vectors := map[int64]string{
+		59:          "287082",
+		1111111109:  "081804",
+		1111111111:  "050471",
+		1234567890:  "005924",
+		2000000000:  "279037",
+		20000000000: "353130",
+	}
+
+	for unix, want := range vectors {
+		got, err := CodeAt(rfcSecret, Step(time.Unix(unix, 0)))
+		if err != nil {
+			t.Fatalf("CodeAt(%d) failed: %v", unix, err)
+		}
+		if got != want {
+			t.Errorf("CodeAt(%d) = %s, want %s", unix, got, want)
+		}
+	}
+}
+
+func TestValidate_AllowsSkewAndReportsStep(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	prev, _ := CodeAt(rfcSecret, Step(now)-1)
+
+	step, ok := Validate(rfcSecret, prev, now, 1)
+	if !ok {
+		t.Fatal("expected previous step to validate with skew 1")
+	}
+	if step != Step(now)-1 {
+		t.Errorf("expected step %d, got %d", Step(now)-1, step)
+	}
+
+	if _, ok := Validate(rfcSecret, prev, now, 0); ok {
+		t.Error("expected previous step to fail without skew")
+	}
+	if _, ok := Validate(rfcSecret, "12345", now, 1); ok {
+		t.Error("expected short code to fail")
+	}
+}
+
+func TestProvisioningURI(t *testing.T) {
+	uri := ProvisioningURI("StudyCollab", "ada@example.com", "JBSWY3DPEHPK3PXP")
+
+	if !strings.HasPrefix(uri, "otpauth://totp/StudyCollab:ada@example.com?") {
+		t.Errorf("unexpected label in %s", uri)
+	}
+	if !strings.Contains(uri, "secret=JBSWY3DPEHPK3PXP") || !strings.Contains(uri, "issuer=StudyCollab") {
+		t.Errorf("missing secret or issuer in %s", uri)
+	}
+}
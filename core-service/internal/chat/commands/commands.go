@@ -0,0 +1,214 @@
+// Package commands parses slash commands typed into chat and keeps the
+// registry of handlers that run them.
+//
+// A message whose text starts with "/" is a command: its first word names
+// the command and the rest are arguments. Arguments may be quoted, and
+// unquoted "key:value" arguments are options. Text starting with "//" is
+// an ordinary message with the first slash removed.
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"core-service/internal/chat/rooms"
+	"core-service/internal/permissions"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrInvalidName = errors.New("command names are lower-case letters, digits and dashes")
+	ErrNoHandler   = errors.New("command has no handler")
+	ErrDuplicate   = errors.New("command is already registered")
+)
+
+var (
+	namePattern   = regexp.MustCompile(`^[a-z][a-z0-9-]{0,31}$`)
+	optionPattern = regexp.MustCompile(`^([a-z]+):(.+)$`)
+)
+
+// Invocation is one run of a command.
+type Invocation struct {
+	Name string
+	// Args are the positional arguments, with quotes removed.
+	Args []string
+	// Options are the key:value arguments.
+	Options  map[string]string
+	UserID   uuid.UUID
+	Username string
+	Room     rooms.Room
+}
+
+// Reply is what a command answers. Only the caller sees it unless
+// Broadcast is set.
+type Reply struct {
+	Text      string
+	Broadcast bool
+}
+
+type Handler func(ctx context.Context, inv Invocation) (Reply, error)
+
+type Command struct {
+	Name        string
+	Usage       string
+	Description string
+	// Permission, when set, must be granted by the caller's role in the
+	// room's group, and the command only works in group rooms.
+	Permission permissions.Permission
+	Handler    Handler
+}
+
+// UsageError is a mistake in how a command was called. Its message is
+// shown to the user.
+type UsageError struct {
+	Message string
+}
+
+func (e *UsageError) Error() string { return e.Message }
+
+func Usagef(format string, args ...interface{}) error {
+	return &UsageError{Message: fmt.Sprintf(format, args...)}
+}
+
+// Registry holds the available commands. It is safe for concurrent use.
+type Registry struct {
+	mu       sync.RWMutex
+	commands map[string]Command
+}
+
+func NewRegistry() *Registry {
+	return &Registry{commands: make(map[string]Command)}
+}
+
+// Register adds a command. Names are unique; registering one twice fails.
+func (r *Registry) Register(cmd Command) error {
+	if !namePattern.MatchString(cmd.Name) {
+		return fmt.Errorf("%q: %w", cmd.Name, ErrInvalidName)
+	}
+	if cmd.Handler == nil {
+		return fmt.Errorf("%q: %w", cmd.Name, ErrNoHandler)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.commands[cmd.Name]; ok {
+		return fmt.Errorf("%q: %w", cmd.Name, ErrDuplicate)
+	}
+	r.commands[cmd.Name] = cmd
+	return nil
+}
+
+func (r *Registry) Lookup(name string) (Command, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	cmd, ok := r.commands[name]
+	return cmd, ok
+}
+
+// List returns every command, sorted by name.
+func (r *Registry) List() []Command {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	out := make([]Command, 0, len(r.commands))
+	for _, cmd := range r.commands {
+		out = append(out, cmd)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// IsCommand reports whether text should be run as a command.
+func IsCommand(text string) bool {
+	return strings.HasPrefix(text, "/") && !strings.HasPrefix(text, "//")
+}
+
+// Unescape turns "//text" into "/text", leaving other text alone.
+func Unescape(text string) string {
+	if strings.HasPrefix(text, "//") {
+		return text[1:]
+	}
+	return text
+}
+
+// Parse splits command text into its name, arguments and options. The
+// caller fills in who ran it and where.
+func Parse(text string) (Invocation, error) {
+	if !IsCommand(text) {
+		return Invocation{}, Usagef("commands start with /")
+	}
+	words, err := split(text[1:])
+	if err != nil {
+		return Invocation{}, err
+	}
+	if len(words) == 0 || words[0].quoted {
+		return Invocation{}, Usagef("type a command name after /")
+	}
+
+	inv := Invocation{Name: strings.ToLower(words[0].text), Options: map[string]string{}}
+	for _, w := range words[1:] {
+		if !w.quoted {
+			if m := optionPattern.FindStringSubmatch(w.text); m != nil {
+				inv.Options[m[1]] = m[2]
+				continue
+			}
+		}
+		inv.Args = append(inv.Args, w.text)
+	}
+	return inv, nil
+}
+
+type word struct {
+	text   string
+	quoted bool
+}
+
+// split breaks text into words at white space. Double quotes group words,
+// including an option's value (due:"next week"), and a backslash inside
+// quotes escapes the next character.
+func split(text string) ([]word, error) {
+	var (
+		words   []word
+		cur     strings.Builder
+		inWord  bool
+		quoted  bool
+		inQuote bool
+		escaped bool
+	)
+	for _, r := range text {
+		switch {
+		case escaped:
+			cur.WriteRune(r)
+			escaped = false
+		case inQuote && r == '\\':
+			escaped = true
+		case r == '"':
+			inQuote = !inQuote
+			if cur.Len() == 0 {
+				quoted = true
+			}
+			inWord = true
+		case !inQuote && (r == ' ' || r == '\t' || r == '\n' || r == '\r'):
+			if inWord {
+				words = append(words, word{text: cur.String(), quoted: quoted})
+				cur.Reset()
+				inWord, quoted = false, false
+			}
+		default:
+			cur.WriteRune(r)
+			inWord = true
+		}
+	}
+	if inQuote {
+		return nil, Usagef("missing closing quote")
+	}
+	if inWord {
+		words = append(words, word{text: cur.String(), quoted: quoted})
+	}
+	return words, nil
+}
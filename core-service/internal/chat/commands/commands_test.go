@@ -0,0 +1,137 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	cases := []struct {
+		text    string
+		name    string
+		args    []string
+		options map[string]string
+	}{
+		{"/tasks", "tasks", nil, map[string]string{}},
+		{"/TASK  Revise ", "task", []string{"Revise"}, map[string]string{}},
+		{`/task "Read ch.4" due:fri`, "task", []string{"Read ch.4"}, map[string]string{"due": "fri"}},
+		{`/task Read ch.4 due:"next friday"`, "task", []string{"Read", "ch.4"}, map[string]string{"due": "next friday"}},
+		{`/task "due:fri" "say \"hi\""`, "task", []string{"due:fri", `say "hi"`}, map[string]string{}},
+		{"/poll 10:30 or 11:00", "poll", []string{"10:30", "or", "11:00"}, map[string]string{}},
+		{`/task ""`, "task", []string{""}, map[string]string{}},
+	}
+	for _, c := range cases {
+		inv, err := Parse(c.text)
+		if err != nil {
+			t.Errorf("Parse(%q) failed: %v", c.text, err)
+			continue
+		}
+		if inv.Name != c.name || !reflect.DeepEqual(inv.Args, c.args) || !reflect.DeepEqual(inv.Options, c.options) {
+			t.Errorf("Parse(%q) = %q %q %v, want %q %q %v", c.text, inv.Name, inv.Args, inv.Options, c.name, c.args, c.options)
+		}
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	for _, text := range []string{"hello", "//not a command", "/", "/   ", `/"task"`, `/task "Read ch.4`} {
+		_, err := Parse(text)
+		var usage *UsageError
+		if !errors.As(err, &usage) {
+			t.Errorf("Parse(%q) error = %v, want a usage error", text, err)
+		}
+	}
+}
+
+func TestIsCommand(t *testing.T) {
+	cases := map[string]bool{
+		"/tasks":       true,
+		"//tasks":      false,
+		"tasks":        false,
+		" /tasks":      false,
+		"":             false,
+		"/ leading sp": true,
+	}
+	for text, want := range cases {
+		if got := IsCommand(text); got != want {
+			t.Errorf("IsCommand(%q) = %v, want %v", text, got, want)
+		}
+	}
+	if got := Unescape("//shrug"); got != "/shrug" {
+		t.Errorf("Unescape(//shrug) = %q", got)
+	}
+	if got := Unescape("plain"); got != "plain" {
+		t.Errorf("Unescape(plain) = %q", got)
+	}
+}
+
+func TestRegistry(t *testing.T) {
+	noop := func(context.Context, Invocation) (Reply, error) { return Reply{}, nil }
+	r := NewRegistry()
+
+	if err := r.Register(Command{Name: "tasks", Handler: noop}); err != nil {
+		t.Fatalf("Register(tasks) failed: %v", err)
+	}
+	if err := r.Register(Command{Name: "help", Handler: noop}); err != nil {
+		t.Fatalf("Register(help) failed: %v", err)
+	}
+	if err := r.Register(Command{Name: "tasks", Handler: noop}); !errors.Is(err, ErrDuplicate) {
+		t.Errorf("duplicate Register error = %v, want ErrDuplicate", err)
+	}
+	if err := r.Register(Command{Name: "Bad Name", Handler: noop}); !errors.Is(err, ErrInvalidName) {
+		t.Errorf("invalid name error = %v, want ErrInvalidName", err)
+	}
+	if err := r.Register(Command{Name: "nohandler"}); !errors.Is(err, ErrNoHandler) {
+		t.Errorf("missing handler error = %v, want ErrNoHandler", err)
+	}
+
+	if _, ok := r.Lookup("tasks"); !ok {
+		t.Error("Lookup(tasks) found nothing")
+	}
+	if _, ok := r.Lookup("poll"); ok {
+		t.Error("Lookup(poll) found an unregistered command")
+	}
+
+	var names []string
+	for _, cmd := range r.List() {
+		names = append(names, cmd.Name)
+	}
+	if want := []string{"help", "tasks"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("List() = %v, want %v", names, want)
+	}
+}
+
+func TestParseDate(t *testing.T) {
+	// A Wednesday.
+	now := time.Date(2026, 3, 4, 15, 30, 0, 0, time.UTC)
+	day := func(d int) time.Time { return time.Date(2026, 3, d, 23, 59, 0, 0, time.UTC) }
+
+	cases := map[string]time.Time{
+		"today":            day(4),
+		"Tomorrow":         day(5),
+		"fri":              day(6),
+		"monday":           day(9),
+		"wed":              day(11),
+		"2026-03-20":       day(20),
+		"2026-03-20T09:15": time.Date(2026, 3, 20, 9, 15, 0, 0, time.UTC),
+		"2026-03-20t09:15": time.Date(2026, 3, 20, 9, 15, 0, 0, time.UTC),
+	}
+	for value, want := range cases {
+		got, err := ParseDate(value, now)
+		if err != nil {
+			t.Errorf("ParseDate(%q) failed: %v", value, err)
+			continue
+		}
+		if !got.Equal(want) {
+			t.Errorf("ParseDate(%q) = %v, want %v", value, got, want)
+		}
+	}
+
+	for _, value := range []string{"someday", "2026-13-01", "03/20"} {
+		if _, err := ParseDate(value, now); err == nil {
+			t.Errorf("ParseDate(%q) should fail", value)
+		}
+	}
+}
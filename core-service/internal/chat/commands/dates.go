@@ -0,0 +1,50 @@
+package commands
+
+import (
+	"strings"
+	"time"
+)
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday, "sunday": time.Sunday,
+	"mon": time.Monday, "monday": time.Monday,
+	"tue": time.Tuesday, "tues": time.Tuesday, "tuesday": time.Tuesday,
+	"wed": time.Wednesday, "wednesday": time.Wednesday,
+	"thu": time.Thursday, "thur": time.Thursday, "thurs": time.Thursday, "thursday": time.Thursday,
+	"fri": time.Friday, "friday": time.Friday,
+	"sat": time.Saturday, "saturday": time.Saturday,
+}
+
+// ParseDate reads a date typed in a command: "today", "tomorrow", a
+// weekday name (the next such day, a week ahead if it is today), a
+// 2006-01-02 date or a 2006-01-02T15:04 time. Days without a time end at
+// 23:59. Everything is in now's location.
+func ParseDate(value string, now time.Time) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	word := strings.ToLower(value)
+	loc := now.Location()
+	endOfDay := func(t time.Time) time.Time {
+		return time.Date(t.Year(), t.Month(), t.Day(), 23, 59, 0, 0, loc)
+	}
+
+	switch word {
+	case "today":
+		return endOfDay(now), nil
+	case "tomorrow":
+		return endOfDay(now.AddDate(0, 0, 1)), nil
+	}
+	if day, ok := weekdays[word]; ok {
+		ahead := (int(day) - int(now.Weekday()) + 7) % 7
+		if ahead == 0 {
+			ahead = 7
+		}
+		return endOfDay(now.AddDate(0, 0, ahead)), nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02T15:04", strings.ToUpper(value), loc); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation(time.DateOnly, value, loc); err == nil {
+		return endOfDay(t), nil
+	}
+	return time.Time{}, Usagef("%q is not a date; use today, tomorrow, a weekday or YYYY-MM-DD", value)
+}
@@ -0,0 +1,52 @@
+// Package fanout carries chat events between core-service instances, so
+// members of a room see each other's messages whichever instance they are
+// connected to, and tracks which users are present in each room across the
+// cluster.
+package fanout
+
+import (
+	"context"
+	"encoding/json"
+)
+
+// Event is published to every instance, including the publisher.
+type Event struct {
+	Room string `json:"room"`
+	// Frame is written verbatim to every local client in Room.
+	Frame json.RawMessage `json:"frame,omitempty"`
+	// RevokeUser asks every instance to drop that user's connections from
+	// Room instead of delivering a frame.
+	RevokeUser string `json:"revoke_user,omitempty"`
+}
+
+// Presence is one user connected to a room. Away is set when every one of
+// their connections is idle.
+type Presence struct {
+	UserID string
+	Away   bool
+}
+
+// Handler receives events in publish order. It runs on the backend's
+// delivery goroutine and must not block for long.
+type Handler func(Event)
+
+type Backend interface {
+	// Subscribe sets the handler for events published by any instance. It
+	// must be called once, before the first Publish.
+	Subscribe(h Handler)
+	Publish(ctx context.Context, ev Event) error
+
+	// Join and Leave count one connection of the user in the room on this
+	// instance. Join marks the user active here.
+	Join(ctx context.Context, room, userID string) error
+	Leave(ctx context.Context, room, userID string) error
+	// SetAway records whether all of the user's connections to the room on
+	// this instance are idle.
+	SetAway(ctx context.Context, room, userID string, away bool) error
+	// Online returns the users with at least one connection to the room on
+	// any live instance, ordered by user ID. A user is away only if they are
+	// away on every instance.
+	Online(ctx context.Context, room string) ([]Presence, error)
+
+	Close() error
+}
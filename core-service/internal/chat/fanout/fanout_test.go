@@ -0,0 +1,181 @@
+package fanout
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+func collect(t *testing.T, b Backend) <-chan Event {
+	t.Helper()
+	out := make(chan Event, 64)
+	b.Subscribe(func(ev Event) { out <- ev })
+	return out
+}
+
+func next(t *testing.T, events <-chan Event) Event {
+	t.Helper()
+	select {
+	case ev := <-events:
+		return ev
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for event")
+		return Event{}
+	}
+}
+
+func TestMemory_DeliversInOrder(t *testing.T) {
+	m := NewMemory()
+	defer m.Close()
+	events := collect(t, m)
+
+	ctx := context.Background()
+	for _, room := range []string{"r1", "r2", "r1"} {
+		if err := m.Publish(ctx, Event{Room: room, Frame: json.RawMessage(`{}`)}); err != nil {
+			t.Fatalf("Publish failed: %v", err)
+		}
+	}
+	m.Publish(ctx, Event{Room: "r1", RevokeUser: "u1"})
+
+	for i, want := range []string{"r1", "r2", "r1", "r1"} {
+		if got := next(t, events).Room; got != want {
+			t.Errorf("event %d: room %s, want %s", i, got, want)
+		}
+	}
+}
+
+func testPresence(t *testing.T, a, b Backend) {
+	t.Helper()
+	ctx := context.Background()
+
+	a.Join(ctx, "room", "u1")
+	a.Join(ctx, "room", "u1")
+	b.Join(ctx, "room", "u2")
+	b.Join(ctx, "other", "u3")
+
+	online, err := a.Online(ctx, "room")
+	if err != nil {
+		t.Fatalf("Online failed: %v", err)
+	}
+	want := []Presence{{UserID: "u1"}, {UserID: "u2"}}
+	if !reflect.DeepEqual(online, want) {
+		t.Errorf("online = %v, want %v", online, want)
+	}
+
+	b.SetAway(ctx, "room", "u2", true)
+	want[1].Away = true
+	if online, _ := a.Online(ctx, "room"); !reflect.DeepEqual(online, want) {
+		t.Errorf("after away online = %v, want %v", online, want)
+	}
+
+	// u1 is still connected once after closing one of two tabs.
+	a.Leave(ctx, "room", "u1")
+	if online, _ := b.Online(ctx, "room"); !reflect.DeepEqual(online, want) {
+		t.Errorf("after one leave online = %v, want %v", online, want)
+	}
+
+	a.Leave(ctx, "room", "u1")
+	b.Leave(ctx, "room", "u2")
+	if online, _ := a.Online(ctx, "room"); len(online) != 0 {
+		t.Errorf("after all left online = %v, want none", online)
+	}
+	b.Leave(ctx, "other", "u3")
+}
+
+func TestMemory_Presence(t *testing.T) {
+	m := NewMemory()
+	defer m.Close()
+	testPresence(t, m, m)
+}
+
+// TestPostgres runs two backends against the database in
+// CHAT_FANOUT_TEST_DSN, standing in for two instances.
+func TestPostgres(t *testing.T) {
+	dsn := os.Getenv("CHAT_FANOUT_TEST_DSN")
+	if dsn == "" {
+		t.Skip("CHAT_FANOUT_TEST_DSN not set")
+	}
+
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+
+	a, err := NewPostgres(db, dsn, zap.NewNop())
+	if err != nil {
+		t.Fatalf("NewPostgres failed: %v", err)
+	}
+	defer a.Close()
+	b, err := NewPostgres(db, dsn, zap.NewNop())
+	if err != nil {
+		t.Fatalf("NewPostgres failed: %v", err)
+	}
+	defer b.Close()
+
+	eventsA, eventsB := collect(t, a), collect(t, b)
+	ctx := context.Background()
+
+	// Listeners connect in the background; publish until both hear us.
+	ready := func(events <-chan Event) {
+		for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); {
+			a.Publish(ctx, Event{Room: "ping"})
+			select {
+			case <-events:
+				return
+			case <-time.After(100 * time.Millisecond):
+			}
+		}
+		t.Fatal("listener never connected")
+	}
+	ready(eventsA)
+	ready(eventsB)
+	drain := func(events <-chan Event) {
+		for {
+			select {
+			case <-events:
+			case <-time.After(200 * time.Millisecond):
+				return
+			}
+		}
+	}
+	drain(eventsA)
+	drain(eventsB)
+
+	small := Event{Room: "r1", Frame: json.RawMessage(`{"v":1}`)}
+	if err := a.Publish(ctx, small); err != nil {
+		t.Fatalf("Publish failed: %v", err)
+	}
+	if got := next(t, eventsB); got.Room != "r1" || string(got.Frame) != `{"v":1}` {
+		t.Errorf("instance b got %+v", got)
+	}
+	if got := next(t, eventsA); got.Room != "r1" {
+		t.Errorf("publisher did not receive its own event: %+v", got)
+	}
+
+	big := make([]byte, 3*notifyLimit)
+	for i := range big {
+		big[i] = 'x'
+	}
+	frame, _ := json.Marshal(map[string]string{"text": string(big)})
+	if err := b.Publish(ctx, Event{Room: "r2", Frame: frame}); err != nil {
+		t.Fatalf("Publish of large event failed: %v", err)
+	}
+	if got := next(t, eventsA); got.Room != "r2" || len(got.Frame) != len(frame) {
+		t.Errorf("spilled event arrived as room %s with %d bytes", got.Room, len(got.Frame))
+	}
+
+	testPresence(t, a, b)
+
+	b.Join(ctx, "room", "u9")
+	b.Close()
+	if online, _ := a.Online(ctx, "room"); len(online) != 0 {
+		t.Errorf("closed instance still reports %v online", online)
+	}
+}
@@ -0,0 +1,118 @@
+package fanout
+
+import (
+	"context"
+	"sort"
+	"sync"
+)
+
+// memoryQueueSize bounds events waiting for delivery before Publish blocks.
+const memoryQueueSize = 256
+
+// Memory delivers events within this process only. It is the default for a
+// single instance.
+type Memory struct {
+	events chan Event
+	done   chan struct{}
+	once   sync.Once
+
+	mu       sync.Mutex
+	presence map[string]map[string]*memberState
+}
+
+type memberState struct {
+	connections int
+	away        bool
+}
+
+func NewMemory() *Memory {
+	return &Memory{
+		events:   make(chan Event, memoryQueueSize),
+		done:     make(chan struct{}),
+		presence: make(map[string]map[string]*memberState),
+	}
+}
+
+func (m *Memory) Subscribe(h Handler) {
+	go func() {
+		for {
+			select {
+			case ev := <-m.events:
+				h(ev)
+			case <-m.done:
+				return
+			}
+		}
+	}()
+}
+
+func (m *Memory) Publish(ctx context.Context, ev Event) error {
+	select {
+	case m.events <- ev:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (m *Memory) Join(ctx context.Context, room, userID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	users, ok := m.presence[room]
+	if !ok {
+		users = make(map[string]*memberState)
+		m.presence[room] = users
+	}
+	state, ok := users[userID]
+	if !ok {
+		state = &memberState{}
+		users[userID] = state
+	}
+	state.connections++
+	state.away = false
+	return nil
+}
+
+func (m *Memory) Leave(ctx context.Context, room, userID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	users := m.presence[room]
+	if state, ok := users[userID]; !ok || state.connections <= 1 {
+		delete(users, userID)
+	} else {
+		state.connections--
+	}
+	if len(users) == 0 {
+		delete(m.presence, room)
+	}
+	return nil
+}
+
+func (m *Memory) SetAway(ctx context.Context, room, userID string, away bool) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if state, ok := m.presence[room][userID]; ok {
+		state.away = away
+	}
+	return nil
+}
+
+func (m *Memory) Online(ctx context.Context, room string) ([]Presence, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	out := make([]Presence, 0, len(m.presence[room]))
+	for userID, state := range m.presence[room] {
+		out = append(out, Presence{UserID: userID, Away: state.away})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].UserID < out[j].UserID })
+	return out, nil
+}
+
+func (m *Memory) Close() error {
+	m.once.Do(func() { close(m.done) })
+	return nil
+}
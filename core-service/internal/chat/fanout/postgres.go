@@ -0,0 +1,294 @@
+package fanout
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const (
+	notifyChannel = "chat_fanout"
+	// notifyLimit keeps payloads under Postgres' 8000 byte NOTIFY limit;
+	// larger events are stored in chat_fanout_spill and sent by reference.
+	notifyLimit = 7900
+
+	heartbeatInterval = 10 * time.Second
+	// nodeTTL is how long an instance counts as alive after its last
+	// heartbeat. Presence held by dead instances is ignored, then removed.
+	nodeTTL    = 3 * heartbeatInterval
+	spillTTL   = time.Minute
+	maxBackoff = 30 * time.Second
+)
+
+type nodeRow struct {
+	NodeID string    `gorm:"primaryKey;type:varchar(64)"`
+	SeenAt time.Time `gorm:"not null;index"`
+}
+
+func (nodeRow) TableName() string {
+	return "chat_nodes"
+}
+
+type presenceRow struct {
+	NodeID      string `gorm:"primaryKey;type:varchar(64)"`
+	Room        string `gorm:"primaryKey;type:varchar(100);index"`
+	UserID      string `gorm:"primaryKey;type:varchar(64)"`
+	Connections int    `gorm:"not null"`
+	Away        bool   `gorm:"not null;default:false"`
+}
+
+func (presenceRow) TableName() string {
+	return "chat_presence"
+}
+
+type spillRow struct {
+	ID        uuid.UUID `gorm:"type:uuid;primaryKey"`
+	Payload   string    `gorm:"type:text;not null"`
+	CreatedAt time.Time `gorm:"index"`
+}
+
+func (spillRow) TableName() string {
+	return "chat_fanout_spill"
+}
+
+// notification is the NOTIFY payload: either the event itself or the ID of
+// its spilled copy.
+type notification struct {
+	Event
+	Spill string `json:"spill,omitempty"`
+}
+
+// Postgres fans events out with LISTEN/NOTIFY and keeps presence in shared
+// tables, so any number of instances can serve the same rooms.
+type Postgres struct {
+	db     *gorm.DB
+	dsn    string
+	nodeID string
+	log    *zap.Logger
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewPostgres migrates the fan-out tables and registers this instance. dsn
+// opens the dedicated listening connection.
+func NewPostgres(db *gorm.DB, dsn string, log *zap.Logger) (*Postgres, error) {
+	if err := db.AutoMigrate(&nodeRow{}, &presenceRow{}, &spillRow{}); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	p := &Postgres{
+		db:     db,
+		dsn:    dsn,
+		nodeID: uuid.NewString(),
+		log:    log.With(zap.String("component", "chat.fanout")),
+		ctx:    ctx,
+		cancel: cancel,
+	}
+
+	if err := p.heartbeat(ctx); err != nil {
+		cancel()
+		return nil, err
+	}
+
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		ticker := time.NewTicker(heartbeatInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if err := p.heartbeat(ctx); err != nil && ctx.Err() == nil {
+					p.log.Warn("fanout heartbeat failed", zap.Error(err))
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return p, nil
+}
+
+// NodeID identifies this instance in the presence tables.
+func (p *Postgres) NodeID() string {
+	return p.nodeID
+}
+
+// heartbeat marks this instance alive and clears what dead instances and
+// delivered spills left behind.
+func (p *Postgres) heartbeat(ctx context.Context) error {
+	now := time.Now()
+	db := p.db.WithContext(ctx)
+
+	if err := db.Exec(
+		`INSERT INTO chat_nodes (node_id, seen_at) VALUES (?, ?)
+		 ON CONFLICT (node_id) DO UPDATE SET seen_at = EXCLUDED.seen_at`,
+		p.nodeID, now,
+	).Error; err != nil {
+		return err
+	}
+
+	dead := now.Add(-2 * nodeTTL)
+	if err := db.Exec(
+		`DELETE FROM chat_presence WHERE node_id IN (SELECT node_id FROM chat_nodes WHERE seen_at < ?)`, dead,
+	).Error; err != nil {
+		return err
+	}
+	if err := db.Where("seen_at < ?", dead).Delete(&nodeRow{}).Error; err != nil {
+		return err
+	}
+	return db.Where("created_at < ?", now.Add(-spillTTL)).Delete(&spillRow{}).Error
+}
+
+func (p *Postgres) Subscribe(h Handler) {
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		backoff := time.Second
+		for {
+			err := p.listen(h)
+			if p.ctx.Err() != nil {
+				return
+			}
+			p.log.Warn("fanout listener disconnected, events may be missed until it reconnects",
+				zap.Error(err), zap.Duration("retry_in", backoff))
+
+			select {
+			case <-time.After(backoff):
+			case <-p.ctx.Done():
+				return
+			}
+			if backoff *= 2; backoff > maxBackoff {
+				backoff = maxBackoff
+			}
+		}
+	}()
+}
+
+// listen holds one LISTEN connection until it fails or the backend closes.
+func (p *Postgres) listen(h Handler) error {
+	conn, err := pgx.Connect(p.ctx, p.dsn)
+	if err != nil {
+		return err
+	}
+	defer conn.Close(context.Background())
+
+	if _, err := conn.Exec(p.ctx, "LISTEN "+notifyChannel); err != nil {
+		return err
+	}
+	p.log.Info("fanout listener connected", zap.String("node_id", p.nodeID))
+
+	for {
+		n, err := conn.WaitForNotification(p.ctx)
+		if err != nil {
+			return err
+		}
+
+		var note notification
+		if err := json.Unmarshal([]byte(n.Payload), &note); err != nil {
+			p.log.Warn("dropping malformed fanout notification", zap.Error(err))
+			continue
+		}
+		if note.Spill != "" {
+			ev, err := p.loadSpill(note.Spill)
+			if err != nil {
+				p.log.Warn("failed to load spilled fanout event", zap.String("spill_id", note.Spill), zap.Error(err))
+				continue
+			}
+			note.Event = ev
+		}
+		h(note.Event)
+	}
+}
+
+func (p *Postgres) loadSpill(id string) (Event, error) {
+	var row spillRow
+	if err := p.db.WithContext(p.ctx).First(&row, "id = ?", id).Error; err != nil {
+		return Event{}, err
+	}
+	var ev Event
+	err := json.Unmarshal([]byte(row.Payload), &ev)
+	return ev, err
+}
+
+func (p *Postgres) Publish(ctx context.Context, ev Event) error {
+	body, err := json.Marshal(notification{Event: ev})
+	if err != nil {
+		return err
+	}
+
+	if len(body) > notifyLimit {
+		row := spillRow{ID: uuid.New(), Payload: string(body), CreatedAt: time.Now()}
+		if err := p.db.WithContext(ctx).Create(&row).Error; err != nil {
+			return err
+		}
+		body, _ = json.Marshal(notification{Spill: row.ID.String()})
+	}
+
+	return p.db.WithContext(ctx).Exec("SELECT pg_notify(?, ?)", notifyChannel, string(body)).Error
+}
+
+func (p *Postgres) Join(ctx context.Context, room, userID string) error {
+	return p.db.WithContext(ctx).Exec(
+		`INSERT INTO chat_presence (node_id, room, user_id, connections, away) VALUES (?, ?, ?, 1, false)
+		 ON CONFLICT (node_id, room, user_id)
+		 DO UPDATE SET connections = chat_presence.connections + 1, away = false`,
+		p.nodeID, room, userID,
+	).Error
+}
+
+func (p *Postgres) Leave(ctx context.Context, room, userID string) error {
+	return p.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec(
+			`UPDATE chat_presence SET connections = connections - 1
+			 WHERE node_id = ? AND room = ? AND user_id = ?`,
+			p.nodeID, room, userID,
+		).Error; err != nil {
+			return err
+		}
+		return tx.Where("node_id = ? AND room = ? AND user_id = ? AND connections <= 0", p.nodeID, room, userID).
+			Delete(&presenceRow{}).Error
+	})
+}
+
+func (p *Postgres) SetAway(ctx context.Context, room, userID string, away bool) error {
+	return p.db.WithContext(ctx).Model(&presenceRow{}).
+		Where("node_id = ? AND room = ? AND user_id = ?", p.nodeID, room, userID).
+		Update("away", away).Error
+}
+
+func (p *Postgres) Online(ctx context.Context, room string) ([]Presence, error) {
+	var users []Presence
+	err := p.db.WithContext(ctx).Raw(
+		`SELECT p.user_id, bool_and(p.away) AS away FROM chat_presence p
+		 JOIN chat_nodes n ON n.node_id = p.node_id
+		 WHERE p.room = ? AND p.connections > 0 AND n.seen_at > ?
+		 GROUP BY p.user_id
+		 ORDER BY p.user_id`,
+		room, time.Now().Add(-nodeTTL),
+	).Scan(&users).Error
+	return users, err
+}
+
+// Close stops listening and withdraws this instance's presence.
+func (p *Postgres) Close() error {
+	p.cancel()
+	p.wg.Wait()
+
+	return p.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("node_id = ?", p.nodeID).Delete(&presenceRow{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("node_id = ?", p.nodeID).Delete(&nodeRow{}).Error
+	})
+}
@@ -0,0 +1,84 @@
+// Package mentions finds @mentions in chat text.
+//
+// "@name" mentions the user with that username, compared without regard
+// to case. "@here" mentions everyone currently in the room and "@admins"
+// the group's admins and owner, so users with those names cannot be
+// mentioned individually.
+package mentions
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	Here   = "here"
+	Admins = "admins"
+)
+
+// maxNameLength bounds a single token; longer runs are not mentions.
+const maxNameLength = 64
+
+// Set is what a message mentions.
+type Set struct {
+	// Usernames are lower-cased, in order of first mention.
+	Usernames []string
+	Here      bool
+	Admins    bool
+}
+
+func (s Set) Empty() bool {
+	return len(s.Usernames) == 0 && !s.Here && !s.Admins
+}
+
+// Parse returns the mentions in text. An @ only starts a mention at the
+// start of the text or after a character that cannot be part of a name, so
+// e-mail addresses are not mentions. Trailing dots and dashes are treated
+// as punctuation.
+func Parse(text string) Set {
+	var set Set
+	seen := map[string]bool{}
+
+	prev := ' '
+	for i := 0; i < len(text); {
+		r, size := utf8.DecodeRuneInString(text[i:])
+		if r != '@' || isNameRune(prev) {
+			prev = r
+			i += size
+			continue
+		}
+
+		prev = r
+		end := i + size
+		for end < len(text) {
+			next, n := utf8.DecodeRuneInString(text[end:])
+			if !isNameRune(next) {
+				break
+			}
+			prev = next
+			end += n
+		}
+		name := strings.TrimRight(text[i+size:end], ".-")
+		i = end
+
+		if name == "" || len(name) > maxNameLength {
+			continue
+		}
+		name = strings.ToLower(name)
+		switch {
+		case name == Here:
+			set.Here = true
+		case name == Admins:
+			set.Admins = true
+		case !seen[name]:
+			seen[name] = true
+			set.Usernames = append(set.Usernames, name)
+		}
+	}
+	return set
+}
+
+func isNameRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || r == '.' || r == '-'
+}
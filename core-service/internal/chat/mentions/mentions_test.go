@@ -0,0 +1,38 @@
+package mentions
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	cases := []struct {
+		text string
+		want Set
+	}{
+		{"no mentions here", Set{}},
+		{"@ada can you look?", Set{Usernames: []string{"ada"}}},
+		{"thanks @Ada and @bob_2, also @ada again", Set{Usernames: []string{"ada", "bob_2"}}},
+		{"ping @grace.hopper.", Set{Usernames: []string{"grace.hopper"}}},
+		{"(@linus) @alan-", Set{Usernames: []string{"linus", "alan"}}},
+		{"mail me at ada@example.com", Set{}},
+		{"@ada@bob", Set{Usernames: []string{"ada"}}},
+		{"@here meeting in 5, @ADMINS please confirm", Set{Here: true, Admins: true}},
+		{"@ and @. are not mentions", Set{}},
+		{"héllo @zoë", Set{Usernames: []string{"zoë"}}},
+	}
+	for _, c := range cases {
+		if got := Parse(c.text); !reflect.DeepEqual(got, c.want) {
+			t.Errorf("Parse(%q) = %+v, want %+v", c.text, got, c.want)
+		}
+	}
+}
+
+func TestParse_Empty(t *testing.T) {
+	if !Parse("hello").Empty() {
+		t.Error("text without mentions should be empty")
+	}
+	if Parse("@here").Empty() {
+		t.Error("@here should not be empty")
+	}
+}
@@ -0,0 +1,112 @@
+// Package polls validates the polls posted in chat and the votes cast in
+// them.
+package polls
+
+import (
+	"errors"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	MinOptions        = 2
+	MaxOptions        = 10
+	MaxQuestionLength = 300
+	MaxOptionLength   = 100
+	// MaxDuration is how far ahead a poll may be set to close.
+	MaxDuration = 90 * 24 * time.Hour
+)
+
+var (
+	ErrNoQuestion       = errors.New("a poll needs a question")
+	ErrQuestionTooLong  = errors.New("the question is too long")
+	ErrTooFewOptions    = errors.New("a poll needs at least two different options")
+	ErrTooManyOptions   = errors.New("a poll can have at most ten options")
+	ErrOptionTooLong    = errors.New("an option is too long")
+	ErrClosesInPast     = errors.New("the closing time has already passed")
+	ErrClosesTooLate    = errors.New("polls can stay open for at most 90 days")
+	ErrUnknownOption    = errors.New("no such option in this poll")
+	ErrSingleChoiceOnly = errors.New("this poll allows only one choice")
+)
+
+// Spec describes a poll to create.
+type Spec struct {
+	Question  string
+	Options   []string
+	Multiple  bool
+	Anonymous bool
+	// ClosesAt, when set, is when voting ends.
+	ClosesAt *time.Time
+}
+
+// Normalize trims the question and options, drops blank and repeated
+// options and checks the result against the limits above.
+func (s Spec) Normalize(now time.Time) (Spec, error) {
+	out := s
+	out.Question = strings.TrimSpace(s.Question)
+	if out.Question == "" {
+		return Spec{}, ErrNoQuestion
+	}
+	if utf8.RuneCountInString(out.Question) > MaxQuestionLength {
+		return Spec{}, ErrQuestionTooLong
+	}
+
+	out.Options = nil
+	seen := map[string]bool{}
+	for _, opt := range s.Options {
+		opt = strings.TrimSpace(opt)
+		key := strings.ToLower(opt)
+		if opt == "" || seen[key] {
+			continue
+		}
+		if utf8.RuneCountInString(opt) > MaxOptionLength {
+			return Spec{}, ErrOptionTooLong
+		}
+		seen[key] = true
+		out.Options = append(out.Options, opt)
+	}
+	if len(out.Options) < MinOptions {
+		return Spec{}, ErrTooFewOptions
+	}
+	if len(out.Options) > MaxOptions {
+		return Spec{}, ErrTooManyOptions
+	}
+
+	if s.ClosesAt != nil {
+		if !s.ClosesAt.After(now) {
+			return Spec{}, ErrClosesInPast
+		}
+		if s.ClosesAt.Sub(now) > MaxDuration {
+			return Spec{}, ErrClosesTooLate
+		}
+	}
+	return out, nil
+}
+
+// Choices checks a ballot against a poll's options and returns it without
+// repeats, in the order given. An empty ballot withdraws the vote.
+func Choices(selected []string, options map[string]bool, multiple bool) ([]string, error) {
+	var out []string
+	seen := map[string]bool{}
+	for _, id := range selected {
+		if !options[id] {
+			return nil, ErrUnknownOption
+		}
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		out = append(out, id)
+	}
+	if !multiple && len(out) > 1 {
+		return nil, ErrSingleChoiceOnly
+	}
+	return out, nil
+}
+
+// Closed reports whether voting has ended, either because the poll was
+// closed or because its closing time has passed.
+func Closed(closesAt, closedAt *time.Time, now time.Time) bool {
+	return closedAt != nil || (closesAt != nil && !now.Before(*closesAt))
+}
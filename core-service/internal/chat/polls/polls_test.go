@@ -0,0 +1,100 @@
+package polls
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSpec_Normalize(t *testing.T) {
+	now := time.Date(2026, 3, 4, 12, 0, 0, 0, time.UTC)
+	later := now.Add(time.Hour)
+
+	got, err := Spec{
+		Question: "  When do we meet? ",
+		Options:  []string{" Tue 6pm", "", "Wed 7pm", "tue 6PM", "Thu 5pm "},
+		Multiple: true,
+		ClosesAt: &later,
+	}.Normalize(now)
+	if err != nil {
+		t.Fatalf("Normalize failed: %v", err)
+	}
+	if got.Question != "When do we meet?" {
+		t.Errorf("Question = %q", got.Question)
+	}
+	if want := []string{"Tue 6pm", "Wed 7pm", "Thu 5pm"}; !reflect.DeepEqual(got.Options, want) {
+		t.Errorf("Options = %q, want %q", got.Options, want)
+	}
+	if !got.Multiple || got.ClosesAt != &later {
+		t.Errorf("Normalize changed the settings: %+v", got)
+	}
+
+	past := now.Add(-time.Minute)
+	tooLate := now.Add(MaxDuration + time.Hour)
+	many := make([]string, MaxOptions+1)
+	for i := range many {
+		many[i] = strings.Repeat("x", i+1)
+	}
+	cases := []struct {
+		spec Spec
+		want error
+	}{
+		{Spec{Question: " ", Options: []string{"a", "b"}}, ErrNoQuestion},
+		{Spec{Question: strings.Repeat("q", MaxQuestionLength+1), Options: []string{"a", "b"}}, ErrQuestionTooLong},
+		{Spec{Question: "q", Options: []string{"a", "A ", ""}}, ErrTooFewOptions},
+		{Spec{Question: "q", Options: many}, ErrTooManyOptions},
+		{Spec{Question: "q", Options: []string{"a", strings.Repeat("b", MaxOptionLength+1)}}, ErrOptionTooLong},
+		{Spec{Question: "q", Options: []string{"a", "b"}, ClosesAt: &past}, ErrClosesInPast},
+		{Spec{Question: "q", Options: []string{"a", "b"}, ClosesAt: &tooLate}, ErrClosesTooLate},
+	}
+	for _, c := range cases {
+		if _, err := c.spec.Normalize(now); !errors.Is(err, c.want) {
+			t.Errorf("Normalize(%+v) error = %v, want %v", c.spec, err, c.want)
+		}
+	}
+}
+
+func TestChoices(t *testing.T) {
+	options := map[string]bool{"o1": true, "o2": true, "o3": true}
+
+	got, err := Choices([]string{"o2", "o1", "o2"}, options, true)
+	if err != nil || !reflect.DeepEqual(got, []string{"o2", "o1"}) {
+		t.Errorf("Choices(multiple) = %v, %v", got, err)
+	}
+	if got, err := Choices(nil, options, false); err != nil || len(got) != 0 {
+		t.Errorf("empty ballot = %v, %v", got, err)
+	}
+	if got, err := Choices([]string{"o3", "o3"}, options, false); err != nil || !reflect.DeepEqual(got, []string{"o3"}) {
+		t.Errorf("repeated single choice = %v, %v", got, err)
+	}
+	if _, err := Choices([]string{"o1", "o2"}, options, false); !errors.Is(err, ErrSingleChoiceOnly) {
+		t.Errorf("two choices in a single-choice poll: error = %v", err)
+	}
+	if _, err := Choices([]string{"o9"}, options, true); !errors.Is(err, ErrUnknownOption) {
+		t.Errorf("unknown option: error = %v", err)
+	}
+}
+
+func TestClosed(t *testing.T) {
+	now := time.Date(2026, 3, 4, 12, 0, 0, 0, time.UTC)
+	before, after := now.Add(-time.Second), now.Add(time.Second)
+
+	cases := []struct {
+		closesAt, closedAt *time.Time
+		want               bool
+	}{
+		{nil, nil, false},
+		{&after, nil, false},
+		{&now, nil, true},
+		{&before, nil, true},
+		{&after, &before, true},
+		{nil, &before, true},
+	}
+	for _, c := range cases {
+		if got := Closed(c.closesAt, c.closedAt, now); got != c.want {
+			t.Errorf("Closed(%v, %v) = %v, want %v", c.closesAt, c.closedAt, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,337 @@
+// Package protocol defines the frames exchanged on the chat WebSocket.
+//
+// Every frame is an envelope {"v", "type", "id", "payload"}. Clients may set
+// id to a nonce of their choosing; the server answers every frame that
+// carries one with exactly one "ack" or "error" frame (or, for "history",
+// the page itself) echoing the same id. schema.json documents each frame
+// type and is checked against these definitions in tests.
+package protocol
+
+import (
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// Version is the protocol version spoken by this server.
+const Version = 1
+
+// MaxIDLength bounds client nonces.
+const MaxIDLength = 64
+
+// Frame types sent by clients.
+const (
+	TypeJoin      = "join"
+	TypeLeave     = "leave"
+	TypeHistory   = "history"
+	TypeBroadcast = "broadcast"
+	TypeEdit      = "edit"
+	TypeDelete    = "delete"
+
+	TypeTypingStart = "typing.start"
+	TypeTypingStop  = "typing.stop"
+	TypeRead        = "read"
+	TypeReply       = "reply"
+	TypeReact       = "react"
+	TypeUnreact     = "unreact"
+	TypePin         = "pin"
+	TypeUnpin       = "unpin"
+	TypeAnnounce    = "announce"
+	TypePoll        = "poll"
+	TypeVote        = "vote"
+	TypeClosePoll   = "poll.close"
+)
+
+// Frame types sent by the server. "history" is also the reply to a history
+// request.
+const (
+	TypeMessage        = "message"
+	TypeAck            = "ack"
+	TypeError          = "error"
+	TypeMessageUpdated = "message.updated"
+	TypeMessageDeleted = "message.deleted"
+	TypePresence       = "presence.changed"
+	TypeTyping         = "typing"
+	TypeReceipt        = "receipt"
+	TypeReaction       = "reaction.updated"
+	TypeNotice         = "notice"
+	TypePinUpdated     = "pin.updated"
+	TypePollUpdated    = "poll.updated"
+)
+
+var (
+	clientTypes = []string{
+		TypeJoin, TypeLeave, TypeHistory, TypeBroadcast, TypeEdit, TypeDelete,
+		TypeTypingStart, TypeTypingStop, TypeRead, TypeReply,
+		TypeReact, TypeUnreact, TypePin, TypeUnpin, TypeAnnounce,
+		TypePoll, TypeVote, TypeClosePoll,
+	}
+	serverTypes = []string{
+		TypeMessage, TypeHistory, TypeAck, TypeError, TypeMessageUpdated, TypeMessageDeleted,
+		TypePresence, TypeTyping, TypeReceipt, TypeReaction, TypeNotice,
+		TypePinUpdated, TypePollUpdated,
+	}
+)
+
+// Presence statuses of a user in a room.
+const (
+	StatusOnline  = "online"
+	StatusAway    = "away"
+	StatusOffline = "offline"
+)
+
+// Error codes carried by error frames.
+const (
+	CodeInvalidFrame       = "invalid_frame"
+	CodeUnsupportedVersion = "unsupported_version"
+	CodeUnknownType        = "unknown_type"
+	CodeInvalidRoom        = "invalid_room"
+	CodeNotJoined          = "not_joined"
+	CodeForbidden          = "forbidden"
+	CodeAccessRevoked      = "access_revoked"
+	CodeInvalidCursor      = "invalid_cursor"
+	CodeNotFound           = "not_found"
+	CodeUnknownCommand     = "unknown_command"
+	CodeInvalidCommand     = "invalid_command"
+	CodeInternal           = "internal"
+)
+
+var (
+	ErrMalformed          = errors.New("malformed frame")
+	ErrUnsupportedVersion = errors.New("unsupported protocol version")
+	ErrInvalidID          = errors.New("frame id is too long")
+)
+
+// Schema is the JSON Schema describing every frame of this version.
+//
+//go:embed schema.json
+var Schema []byte
+
+type Envelope struct {
+	V       int             `json:"v"`
+	Type    string          `json:"type"`
+	ID      string          `json:"id,omitempty"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
+// Decode parses a client frame. Frames without "v" come from clients that
+// predate the envelope and carry their fields at the top level; they are
+// read as version 1 frames with the whole frame as payload.
+func Decode(raw []byte) (*Envelope, error) {
+	var env Envelope
+	if err := json.Unmarshal(raw, &env); err != nil {
+		return nil, ErrMalformed
+	}
+
+	if env.V == 0 {
+		env.V = Version
+		env.Payload = raw
+	}
+	if env.V != Version {
+		return &env, ErrUnsupportedVersion
+	}
+	if env.Type == "" {
+		return &env, ErrMalformed
+	}
+	if len(env.ID) > MaxIDLength {
+		env.ID = ""
+		return &env, ErrInvalidID
+	}
+	return &env, nil
+}
+
+// IsClientType reports whether t is a frame type clients may send.
+func IsClientType(t string) bool {
+	for _, ct := range clientTypes {
+		if ct == t {
+			return true
+		}
+	}
+	return false
+}
+
+// Encode builds a server frame.
+func Encode(frameType, id string, payload interface{}) ([]byte, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(Envelope{V: Version, Type: frameType, ID: id, Payload: body})
+}
+
+// Author identifies who wrote a message.
+type Author struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type Attachment struct {
+	ID       string `json:"id"`
+	FileURL  string `json:"file_url"`
+	FileType string `json:"file_type"`
+	FileSize int64  `json:"file_size"`
+}
+
+// Message is the payload of "message" frames and the element of history
+// pages. Deleted messages keep their place with Deleted set and no content.
+// Replies carry ParentID; messages with replies carry ReplyCount and
+// LatestReply. Kind is "text", "announcement" or "poll" (with Poll set and
+// the question as Text), and pinned messages carry PinnedAt and PinnedBy.
+type Message struct {
+	ID          string        `json:"id"`
+	Room        string        `json:"room"`
+	User        Author        `json:"user"`
+	Kind        string        `json:"kind,omitempty"`
+	Text        string        `json:"text"`
+	Attachments []Attachment  `json:"attachments"`
+	Timestamp   time.Time     `json:"timestamp"`
+	EditedAt    *time.Time    `json:"edited_at,omitempty"`
+	Deleted     bool          `json:"deleted,omitempty"`
+	ParentID    string        `json:"parent_id,omitempty"`
+	ReplyCount  int           `json:"reply_count,omitempty"`
+	LatestReply *ReplySummary `json:"latest_reply,omitempty"`
+	Reactions   []Reaction    `json:"reactions,omitempty"`
+	PinnedAt    *time.Time    `json:"pinned_at,omitempty"`
+	PinnedBy    string        `json:"pinned_by,omitempty"`
+	Poll        *Poll         `json:"poll,omitempty"`
+}
+
+// Reaction counts one emoji on a message. Users lists who reacted, in the
+// order they did.
+type Reaction struct {
+	Emoji string   `json:"emoji"`
+	Count int      `json:"count"`
+	Users []string `json:"users"`
+}
+
+// ReplySummary describes the newest reply in a thread.
+type ReplySummary struct {
+	ID        string    `json:"id"`
+	User      Author    `json:"user"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// ThreadPage is one page of a thread's replies, oldest first. NextCursor
+// is null once the newest reply is reached.
+type ThreadPage struct {
+	Parent     *Message   `json:"parent"`
+	Replies    []*Message `json:"replies"`
+	NextCursor *string    `json:"next_cursor"`
+}
+
+// HistoryPage is one page of older messages, oldest first. NextCursor is
+// null once the start of the room is reached.
+type HistoryPage struct {
+	Room       string     `json:"room"`
+	Messages   []*Message `json:"messages"`
+	NextCursor *string    `json:"next_cursor"`
+}
+
+// Ack confirms a client frame. For frames that post a message ("broadcast",
+// "reply", "announce" and "poll") it carries the persisted message ID and
+// timestamp, for "join" the number of users in the room.
+type Ack struct {
+	Room      string     `json:"room,omitempty"`
+	Online    int        `json:"online,omitempty"`
+	MessageID string     `json:"message_id,omitempty"`
+	Timestamp *time.Time `json:"timestamp,omitempty"`
+}
+
+type Error struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	Room    string `json:"room,omitempty"`
+}
+
+type MessageUpdated struct {
+	Room    string   `json:"room"`
+	Message *Message `json:"message"`
+}
+
+type MessageDeleted struct {
+	Room      string    `json:"room"`
+	MessageID string    `json:"message_id"`
+	DeletedAt time.Time `json:"deleted_at"`
+}
+
+// PresenceChanged tells a room that a user's status changed.
+type PresenceChanged struct {
+	Room   string `json:"room"`
+	UserID string `json:"user_id"`
+	Status string `json:"status"`
+}
+
+// Typing is sent when a user starts typing and again when they stop or
+// their typing.start expires.
+type Typing struct {
+	Room   string `json:"room"`
+	User   Author `json:"user"`
+	Typing bool   `json:"typing"`
+}
+
+// Receipt tells a room how far a user has read.
+type Receipt struct {
+	Room      string    `json:"room"`
+	UserID    string    `json:"user_id"`
+	MessageID string    `json:"message_id"`
+	ReadAt    time.Time `json:"read_at"`
+}
+
+// ReactionUpdated tells a room that a user added or removed a reaction.
+// Count is the emoji's new total on the message.
+type ReactionUpdated struct {
+	Room      string `json:"room"`
+	MessageID string `json:"message_id"`
+	UserID    string `json:"user_id"`
+	Emoji     string `json:"emoji"`
+	Added     bool   `json:"added"`
+	Count     int    `json:"count"`
+}
+
+// Notice is the output of a slash command. Ephemeral notices are sent only
+// to the user who ran the command; the others go to the whole room.
+type Notice struct {
+	Room      string `json:"room"`
+	Command   string `json:"command"`
+	User      Author `json:"user"`
+	Text      string `json:"text"`
+	Ephemeral bool   `json:"ephemeral,omitempty"`
+}
+
+// PinUpdated tells a room that a message was pinned or unpinned. Message is
+// only set when it was pinned.
+type PinUpdated struct {
+	Room      string   `json:"room"`
+	MessageID string   `json:"message_id"`
+	UserID    string   `json:"user_id"`
+	Pinned    bool     `json:"pinned"`
+	Message   *Message `json:"message,omitempty"`
+}
+
+// Poll is the current state of a poll. Users lists who chose an option,
+// unless the poll is anonymous; Voters counts everyone who voted.
+type Poll struct {
+	ID        string       `json:"id"`
+	Options   []PollOption `json:"options"`
+	Multiple  bool         `json:"multiple"`
+	Anonymous bool         `json:"anonymous"`
+	ClosesAt  *time.Time   `json:"closes_at,omitempty"`
+	Closed    bool         `json:"closed"`
+	Voters    int          `json:"voters"`
+}
+
+type PollOption struct {
+	ID    string   `json:"id"`
+	Text  string   `json:"text"`
+	Votes int      `json:"votes"`
+	Users []string `json:"users,omitempty"`
+}
+
+// PollUpdated tells a room a poll's new totals after a vote, or that it
+// closed.
+type PollUpdated struct {
+	Room      string `json:"room"`
+	MessageID string `json:"message_id"`
+	Poll      *Poll  `json:"poll"`
+}
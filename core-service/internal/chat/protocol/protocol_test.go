@@ -0,0 +1,370 @@
+package protocol
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+// validator checks documents against the subset of JSON Schema used by
+// schema.json: $ref, oneOf, type, const, enum, required, properties,
+// additionalProperties, items, minItems, maxItems and maxLength.
+type validator struct {
+	defs map[string]map[string]interface{}
+}
+
+func loadSchema(t *testing.T) (map[string]interface{}, *validator) {
+	t.Helper()
+
+	var root map[string]interface{}
+	if err := json.Unmarshal(Schema, &root); err != nil {
+		t.Fatalf("schema.json is not valid JSON: %v", err)
+	}
+	v := &validator{defs: map[string]map[string]interface{}{}}
+	for name, def := range root["$defs"].(map[string]interface{}) {
+		v.defs[name] = def.(map[string]interface{})
+	}
+	return root, v
+}
+
+func (v *validator) validate(schema map[string]interface{}, doc interface{}, path string) error {
+	if ref, ok := schema["$ref"].(string); ok {
+		def, ok := v.defs[strings.TrimPrefix(ref, "#/$defs/")]
+		if !ok {
+			return fmt.Errorf("%s: unresolved $ref %s", path, ref)
+		}
+		return v.validate(def, doc, path)
+	}
+
+	if options, ok := schema["oneOf"].([]interface{}); ok {
+		matched := 0
+		for _, opt := range options {
+			if v.validate(opt.(map[string]interface{}), doc, path) == nil {
+				matched++
+			}
+		}
+		if matched != 1 {
+			return fmt.Errorf("%s: matches %d oneOf branches", path, matched)
+		}
+	}
+
+	if c, ok := schema["const"]; ok && c != doc {
+		return fmt.Errorf("%s: want %v, got %v", path, c, doc)
+	}
+	if enum, ok := schema["enum"].([]interface{}); ok {
+		found := false
+		for _, e := range enum {
+			found = found || e == doc
+		}
+		if !found {
+			return fmt.Errorf("%s: %v not in enum", path, doc)
+		}
+	}
+
+	if typ, ok := schema["type"]; ok {
+		var allowed []interface{}
+		if list, isList := typ.([]interface{}); isList {
+			allowed = list
+		} else {
+			allowed = []interface{}{typ}
+		}
+		found := false
+		for _, a := range allowed {
+			found = found || jsonType(doc, a.(string))
+		}
+		if !found {
+			return fmt.Errorf("%s: %v is not of type %v", path, doc, typ)
+		}
+	}
+
+	if max, ok := schema["maxLength"].(float64); ok {
+		if s, isString := doc.(string); isString && len(s) > int(max) {
+			return fmt.Errorf("%s: longer than %v", path, max)
+		}
+	}
+
+	if obj, ok := doc.(map[string]interface{}); ok {
+		props, _ := schema["properties"].(map[string]interface{})
+		if required, ok := schema["required"].([]interface{}); ok {
+			for _, r := range required {
+				if _, present := obj[r.(string)]; !present {
+					return fmt.Errorf("%s: missing %s", path, r)
+				}
+			}
+		}
+		for key, val := range obj {
+			prop, known := props[key]
+			if !known {
+				if schema["additionalProperties"] == false {
+					return fmt.Errorf("%s: unexpected property %s", path, key)
+				}
+				continue
+			}
+			if err := v.validate(prop.(map[string]interface{}), val, path+"."+key); err != nil {
+				return err
+			}
+		}
+	}
+
+	if arr, ok := doc.([]interface{}); ok {
+		if min, ok := schema["minItems"].(float64); ok && len(arr) < int(min) {
+			return fmt.Errorf("%s: fewer than %v items", path, min)
+		}
+		if max, ok := schema["maxItems"].(float64); ok && len(arr) > int(max) {
+			return fmt.Errorf("%s: more than %v items", path, max)
+		}
+		if items, ok := schema["items"].(map[string]interface{}); ok {
+			for i, item := range arr {
+				if err := v.validate(items, item, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func jsonType(doc interface{}, typ string) bool {
+	switch typ {
+	case "object":
+		_, ok := doc.(map[string]interface{})
+		return ok
+	case "array":
+		_, ok := doc.([]interface{})
+		return ok
+	case "string":
+		_, ok := doc.(string)
+		return ok
+	case "integer":
+		f, ok := doc.(float64)
+		return ok && f == float64(int64(f))
+	case "boolean":
+		_, ok := doc.(bool)
+		return ok
+	case "null":
+		return doc == nil
+	}
+	return false
+}
+
+func (v *validator) validateFrame(t *testing.T, def string, frame []byte) error {
+	t.Helper()
+	var doc interface{}
+	if err := json.Unmarshal(frame, &doc); err != nil {
+		t.Fatalf("frame is not JSON: %v", err)
+	}
+	return v.validate(map[string]interface{}{"$ref": "#/$defs/" + def}, doc, def)
+}
+
+func TestSchema_DocumentsEveryFrameType(t *testing.T) {
+	root, v := loadSchema(t)
+
+	want := map[string]bool{}
+	for _, ct := range clientTypes {
+		want["client."+ct] = true
+	}
+	for _, st := range serverTypes {
+		want["server."+st] = true
+	}
+
+	for name := range want {
+		if _, ok := v.defs[name]; !ok {
+			t.Errorf("schema.json has no definition for %s", name)
+		}
+	}
+	for name := range v.defs {
+		if (strings.HasPrefix(name, "client.") || strings.HasPrefix(name, "server.")) && !want[name] {
+			t.Errorf("schema.json documents %s, which the server does not know", name)
+		}
+	}
+	if got := len(root["oneOf"].([]interface{})); got != len(want) {
+		t.Errorf("top-level oneOf lists %d frames, want %d", got, len(want))
+	}
+
+	codes := v.defs["server.error"]["properties"].(map[string]interface{})["payload"].(map[string]interface{})["properties"].(map[string]interface{})["code"].(map[string]interface{})["enum"].([]interface{})
+	documented := map[string]bool{}
+	for _, c := range codes {
+		documented[c.(string)] = true
+	}
+	for _, c := range []string{
+		CodeInvalidFrame, CodeUnsupportedVersion, CodeUnknownType, CodeInvalidRoom, CodeNotJoined,
+		CodeForbidden, CodeAccessRevoked, CodeInvalidCursor, CodeNotFound, CodeUnknownCommand,
+		CodeInvalidCommand, CodeInternal,
+	} {
+		if !documented[c] {
+			t.Errorf("error code %s is not in schema.json", c)
+		}
+	}
+}
+
+func TestSchema_ServerFrames(t *testing.T) {
+	_, v := loadSchema(t)
+
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	cursor := "3c1f1bb8-5a5c-4f7e-9d39-8b3f4c0e7a11"
+	msg := &Message{
+		ID:        "b0a7d7a4-3f43-4d7e-8a55-0d1f2c3e4b5a",
+		Room:      "group:5d6f3a10-1111-4c2b-9e4f-7a8b9c0d1e2f",
+		User:      Author{ID: "u1", Name: "ada"},
+		Text:      "hello",
+		Timestamp: now,
+		Reactions: []Reaction{{Emoji: "👍", Count: 2, Users: []string{"u2", "u3"}}},
+		Attachments: []Attachment{
+			{ID: "a1", FileURL: "http://files/a1", FileType: "image/png", FileSize: 12},
+		},
+	}
+	tombstone := &Message{ID: "m2", Room: msg.Room, User: msg.User, Timestamp: now, Deleted: true}
+	parent := &Message{
+		ID: "m3", Room: msg.Room, User: msg.User, Text: "question", Timestamp: now,
+		ReplyCount:  2,
+		LatestReply: &ReplySummary{ID: "m4", User: Author{ID: "u2", Name: "bob"}, Timestamp: now},
+	}
+	announcement := &Message{
+		ID: "m5", Room: msg.Room, User: msg.User, Kind: "announcement", Text: "exam moved", Timestamp: now,
+		PinnedAt: &now, PinnedBy: "u1",
+	}
+	closes := now.Add(time.Hour)
+	poll := &Poll{
+		ID: "p1", Multiple: true, ClosesAt: &closes, Voters: 2,
+		Options: []PollOption{
+			{ID: "o1", Text: "Tue 6pm", Votes: 2, Users: []string{"u1", "u2"}},
+			{ID: "o2", Text: "Wed 7pm", Votes: 0},
+		},
+	}
+	pollMsg := &Message{ID: "m6", Room: msg.Room, User: msg.User, Kind: "poll", Text: "When do we meet?", Timestamp: now, Poll: poll}
+	reply := &Message{ID: "m4", Room: msg.Room, User: Author{ID: "u2", Name: "bob"}, Text: "answer", Timestamp: now, ParentID: parent.ID}
+
+	frames := []struct {
+		def     string
+		typ, id string
+		payload interface{}
+	}{
+		{"server.message", TypeMessage, "", msg},
+		{"server.message", TypeMessage, "", tombstone},
+		{"server.message", TypeMessage, "", reply},
+		{"server.message.updated", TypeMessageUpdated, "", MessageUpdated{Room: msg.Room, Message: parent}},
+		{"server.history", TypeHistory, "n-1", HistoryPage{Room: msg.Room, Messages: []*Message{msg, tombstone}, NextCursor: &cursor}},
+		{"server.history", TypeHistory, "", HistoryPage{Room: msg.Room, Messages: []*Message{}}},
+		{"server.ack", TypeAck, "n-2", Ack{Room: msg.Room, MessageID: msg.ID, Timestamp: &now}},
+		{"server.ack", TypeAck, "n-3", Ack{Room: msg.Room, Online: 4}},
+		{"server.error", TypeError, "n-4", Error{Code: CodeForbidden, Message: "no", Room: msg.Room}},
+		{"server.error", TypeError, "", Error{Code: CodeInvalidFrame, Message: "bad frame"}},
+		{"server.message.updated", TypeMessageUpdated, "", MessageUpdated{Room: msg.Room, Message: msg}},
+		{"server.message.deleted", TypeMessageDeleted, "", MessageDeleted{Room: msg.Room, MessageID: msg.ID, DeletedAt: now}},
+		{"server.presence.changed", TypePresence, "", PresenceChanged{Room: msg.Room, UserID: "u1", Status: StatusAway}},
+		{"server.typing", TypeTyping, "", Typing{Room: msg.Room, User: msg.User, Typing: true}},
+		{"server.receipt", TypeReceipt, "", Receipt{Room: msg.Room, UserID: "u2", MessageID: msg.ID, ReadAt: now}},
+		{"server.reaction.updated", TypeReaction, "", ReactionUpdated{Room: msg.Room, MessageID: msg.ID, UserID: "u2", Emoji: "👍", Added: false, Count: 1}},
+		{"server.notice", TypeNotice, "", Notice{Room: msg.Room, Command: "task", User: msg.User, Text: "ada added a task"}},
+		{"server.notice", TypeNotice, "", Notice{Room: msg.Room, Command: "tasks", User: msg.User, Text: "No upcoming tasks", Ephemeral: true}},
+		{"server.message", TypeMessage, "", announcement},
+		{"server.pin.updated", TypePinUpdated, "", PinUpdated{Room: msg.Room, MessageID: announcement.ID, UserID: "u1", Pinned: true, Message: announcement}},
+		{"server.pin.updated", TypePinUpdated, "", PinUpdated{Room: msg.Room, MessageID: announcement.ID, UserID: "u1"}},
+		{"server.message", TypeMessage, "", pollMsg},
+		{"server.poll.updated", TypePollUpdated, "", PollUpdated{Room: msg.Room, MessageID: pollMsg.ID, Poll: poll}},
+		{"server.poll.updated", TypePollUpdated, "", PollUpdated{Room: msg.Room, MessageID: pollMsg.ID, Poll: &Poll{ID: "p2", Anonymous: true, Closed: true, Options: []PollOption{{ID: "o3", Text: "yes", Votes: 4}}, Voters: 4}}},
+	}
+
+	for _, f := range frames {
+		raw, err := Encode(f.typ, f.id, f.payload)
+		if err != nil {
+			t.Fatalf("Encode(%s) failed: %v", f.typ, err)
+		}
+		if err := v.validateFrame(t, f.def, raw); err != nil {
+			t.Errorf("%s does not match the schema: %v\n%s", f.typ, err, raw)
+		}
+	}
+
+	bad, _ := Encode(TypeError, "", Error{Code: "teapot", Message: "?"})
+	if v.validateFrame(t, "server.error", bad) == nil {
+		t.Error("expected an undocumented error code to be rejected")
+	}
+}
+
+func TestSchema_ClientFrames(t *testing.T) {
+	_, v := loadSchema(t)
+
+	valid := map[string]string{
+		"client.join":         `{"v":1,"type":"join","id":"n1","payload":{"room":"group:g1"}}`,
+		"client.leave":        `{"v":1,"type":"leave","payload":{"room":"dm:u2"}}`,
+		"client.history":      `{"v":1,"type":"history","id":"n2","payload":{"room":"group:g1","before":"m1","limit":20}}`,
+		"client.broadcast":    `{"v":1,"type":"broadcast","id":"n3","payload":{"room":"group:g1","text":"hi","attachments":[{"file_id":"f1","file_name":"a.png","file_type":"image/png","file_size":3}]}}`,
+		"client.edit":         `{"v":1,"type":"edit","id":"n4","payload":{"room":"group:g1","message_id":"m1","text":"fixed"}}`,
+		"client.delete":       `{"v":1,"type":"delete","payload":{"room":"group:g1","message_id":"m1"}}`,
+		"client.typing.start": `{"v":1,"type":"typing.start","payload":{"room":"group:g1"}}`,
+		"client.typing.stop":  `{"v":1,"type":"typing.stop","id":"n5","payload":{"room":"group:g1"}}`,
+		"client.read":         `{"v":1,"type":"read","id":"n6","payload":{"room":"group:g1","message_id":"m1"}}`,
+		"client.reply":        `{"v":1,"type":"reply","id":"n7","payload":{"room":"group:g1","message_id":"m1","text":"agreed"}}`,
+		"client.react":        `{"v":1,"type":"react","id":"n8","payload":{"room":"group:g1","message_id":"m1","emoji":"🎉"}}`,
+		"client.unreact":      `{"v":1,"type":"unreact","payload":{"room":"group:g1","message_id":"m1","emoji":"🎉"}}`,
+		"client.pin":          `{"v":1,"type":"pin","id":"n9","payload":{"room":"group:g1","message_id":"m1"}}`,
+		"client.unpin":        `{"v":1,"type":"unpin","payload":{"room":"group:g1","message_id":"m1"}}`,
+		"client.announce":     `{"v":1,"type":"announce","id":"n10","payload":{"room":"group:g1","text":"Exam moved to Friday"}}`,
+		"client.poll":         `{"v":1,"type":"poll","id":"n11","payload":{"room":"group:g1","question":"When?","options":["Tue","Wed"],"multiple":true,"closes_at":"2026-03-02T18:00:00Z"}}`,
+		"client.vote":         `{"v":1,"type":"vote","id":"n12","payload":{"room":"group:g1","message_id":"m6","option_ids":["o1"]}}`,
+		"client.poll.close":   `{"v":1,"type":"poll.close","payload":{"room":"group:g1","message_id":"m6"}}`,
+	}
+	for def, frame := range valid {
+		if err := v.validateFrame(t, def, []byte(frame)); err != nil {
+			t.Errorf("%s: %v", def, err)
+		}
+		env, err := Decode([]byte(frame))
+		if err != nil {
+			t.Errorf("Decode(%s) failed: %v", def, err)
+			continue
+		}
+		if !IsClientType(env.Type) || "client."+env.Type != def {
+			t.Errorf("Decode(%s) gave type %q", def, env.Type)
+		}
+	}
+
+	invalid := map[string]string{
+		"client.join":      `{"v":1,"type":"join","payload":{}}`,
+		"client.broadcast": `{"v":1,"type":"broadcast","payload":{"room":"group:g1"}}`,
+		"client.edit":      `{"v":2,"type":"edit","payload":{"room":"group:g1","message_id":"m1","text":"x"}}`,
+		"client.delete":    `{"v":1,"type":"delete","payload":{"room":"group:g1","message_id":"m1","extra":true}}`,
+		"client.reply":     `{"v":1,"type":"reply","payload":{"room":"group:g1","text":"no parent"}}`,
+		"client.react":     `{"v":1,"type":"react","payload":{"room":"group:g1","message_id":"m1"}}`,
+		"client.pin":       `{"v":1,"type":"pin","payload":{"room":"group:g1"}}`,
+		"client.poll":      `{"v":1,"type":"poll","payload":{"room":"group:g1","question":"When?","options":["Tue"]}}`,
+		"client.vote":      `{"v":1,"type":"vote","payload":{"room":"group:g1","message_id":"m6"}}`,
+	}
+	for def, frame := range invalid {
+		if v.validateFrame(t, def, []byte(frame)) == nil {
+			t.Errorf("%s: expected %s to be rejected", def, frame)
+		}
+	}
+}
+
+func TestDecode(t *testing.T) {
+	env, err := Decode([]byte(`{"type":"broadcast","room":"g1","text":"hi"}`))
+	if err != nil {
+		t.Fatalf("legacy frame rejected: %v", err)
+	}
+	if env.V != Version || env.Type != TypeBroadcast {
+		t.Errorf("legacy frame decoded as %+v", env)
+	}
+	var payload struct{ Room, Text string }
+	if err := json.Unmarshal(env.Payload, &payload); err != nil || payload.Room != "g1" || payload.Text != "hi" {
+		t.Errorf("legacy payload not readable: %+v, %v", payload, err)
+	}
+
+	if _, err := Decode([]byte(`{"v":2,"type":"join","payload":{}}`)); !errors.Is(err, ErrUnsupportedVersion) {
+		t.Errorf("want ErrUnsupportedVersion, got %v", err)
+	}
+	if _, err := Decode([]byte(`not json`)); !errors.Is(err, ErrMalformed) {
+		t.Errorf("want ErrMalformed, got %v", err)
+	}
+	if _, err := Decode([]byte(`{"v":1,"payload":{}}`)); !errors.Is(err, ErrMalformed) {
+		t.Errorf("missing type: want ErrMalformed, got %v", err)
+	}
+	if env, err := Decode([]byte(`{"v":1,"type":"join","id":"` + strings.Repeat("x", MaxIDLength+1) + `"}`)); !errors.Is(err, ErrInvalidID) || env.ID != "" {
+		t.Errorf("long id: want ErrInvalidID with id cleared, got %v", err)
+	}
+	if IsClientType(TypeAck) {
+		t.Error("ack must not be accepted from clients")
+	}
+}
@@ -0,0 +1,145 @@
+// Package rooms parses the room identifiers clients use on the chat socket.
+//
+// A group room is "group:<groupID>"; a bare "<groupID>" is still accepted
+// from older clients. A direct-message room between two users is
+// "dm:<userA>:<userB>" with the IDs in ascending order, and a client may
+// open one with just "dm:<otherUserID>". A small-group conversation is
+// "conv:<conversationID>".
+package rooms
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+type Kind string
+
+const (
+	Group        Kind = "group"
+	Direct       Kind = "dm"
+	Conversation Kind = "conv"
+)
+
+var ErrInvalidRoom = errors.New("invalid room")
+
+type Room struct {
+	Kind    Kind
+	GroupID uuid.UUID
+	// Users holds both participants of a direct room in ascending order.
+	Users          [2]uuid.UUID
+	ConversationID uuid.UUID
+}
+
+// Parse resolves a room identifier sent by the user self.
+func Parse(raw string, self uuid.UUID) (Room, error) {
+	kind, rest, typed := strings.Cut(raw, ":")
+	if !typed {
+		kind, rest = string(Group), raw
+	}
+
+	switch Kind(kind) {
+	case Group:
+		id, err := uuid.Parse(rest)
+		if err != nil {
+			return Room{}, ErrInvalidRoom
+		}
+		return Room{Kind: Group, GroupID: id}, nil
+
+	case Direct:
+		parts := strings.Split(rest, ":")
+		var a, b uuid.UUID
+		var err error
+		switch len(parts) {
+		case 1:
+			a = self
+			b, err = uuid.Parse(parts[0])
+		case 2:
+			if a, err = uuid.Parse(parts[0]); err == nil {
+				b, err = uuid.Parse(parts[1])
+			}
+		default:
+			return Room{}, ErrInvalidRoom
+		}
+		if err != nil || a == b || (a != self && b != self) {
+			return Room{}, ErrInvalidRoom
+		}
+		return NewDirect(a, b), nil
+
+	case Conversation:
+		id, err := uuid.Parse(rest)
+		if err != nil {
+			return Room{}, ErrInvalidRoom
+		}
+		return Room{Kind: Conversation, ConversationID: id}, nil
+	}
+
+	return Room{}, ErrInvalidRoom
+}
+
+// FromKey is the inverse of Key.
+func FromKey(key string) (Room, error) {
+	if strings.HasPrefix(key, string(Direct)+":") {
+		parts := strings.Split(strings.TrimPrefix(key, string(Direct)+":"), ":")
+		if len(parts) != 2 {
+			return Room{}, ErrInvalidRoom
+		}
+		a, errA := uuid.Parse(parts[0])
+		b, errB := uuid.Parse(parts[1])
+		if errA != nil || errB != nil || a == b {
+			return Room{}, ErrInvalidRoom
+		}
+		return NewDirect(a, b), nil
+	}
+
+	if rest, ok := strings.CutPrefix(key, string(Conversation)+":"); ok {
+		id, err := uuid.Parse(rest)
+		if err != nil {
+			return Room{}, ErrInvalidRoom
+		}
+		return Room{Kind: Conversation, ConversationID: id}, nil
+	}
+
+	id, err := uuid.Parse(key)
+	if err != nil {
+		return Room{}, ErrInvalidRoom
+	}
+	return Room{Kind: Group, GroupID: id}, nil
+}
+
+// NewDirect returns the direct room between two users.
+func NewDirect(a, b uuid.UUID) Room {
+	if strings.Compare(a.String(), b.String()) > 0 {
+		a, b = b, a
+	}
+	return Room{Kind: Direct, Users: [2]uuid.UUID{a, b}}
+}
+
+// Key is the room's hub and chat_messages.room_id value. Group rooms keep
+// the bare group ID that existing messages were stored under.
+func (r Room) Key() string {
+	switch r.Kind {
+	case Direct:
+		return string(Direct) + ":" + r.Users[0].String() + ":" + r.Users[1].String()
+	case Conversation:
+		return string(Conversation) + ":" + r.ConversationID.String()
+	}
+	return r.GroupID.String()
+}
+
+// String is the typed identifier sent to clients.
+func (r Room) String() string {
+	if r.Kind == Group {
+		return string(Group) + ":" + r.GroupID.String()
+	}
+	return r.Key()
+}
+
+// Other returns the participant of a direct room that is not self.
+func (r Room) Other(self uuid.UUID) uuid.UUID {
+	if r.Users[0] == self {
+		return r.Users[1]
+	}
+	return r.Users[0]
+}
@@ -0,0 +1,89 @@
+package rooms
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+var (
+	alice = uuid.MustParse("11111111-1111-1111-1111-111111111111")
+	bob   = uuid.MustParse("22222222-2222-2222-2222-222222222222")
+	carol = uuid.MustParse("33333333-3333-3333-3333-333333333333")
+	group = uuid.MustParse("99999999-9999-9999-9999-999999999999")
+)
+
+func TestParseGroup(t *testing.T) {
+	for _, raw := range []string{"group:" + group.String(), group.String()} {
+		r, err := Parse(raw, alice)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", raw, err)
+		}
+		if r.Kind != Group || r.GroupID != group {
+			t.Errorf("Parse(%q) = %+v", raw, r)
+		}
+		if r.Key() != group.String() || r.String() != "group:"+group.String() {
+			t.Errorf("unexpected key %q / id %q", r.Key(), r.String())
+		}
+	}
+}
+
+func TestParseDirect(t *testing.T) {
+	short, err := Parse("dm:"+alice.String(), bob)
+	if err != nil {
+		t.Fatalf("Parse short form: %v", err)
+	}
+	full, err := Parse("dm:"+bob.String()+":"+alice.String(), alice)
+	if err != nil {
+		t.Fatalf("Parse full form: %v", err)
+	}
+	if short.Key() != full.Key() {
+		t.Errorf("both forms should resolve to one room: %q vs %q", short.Key(), full.Key())
+	}
+	if want := "dm:" + alice.String() + ":" + bob.String(); full.Key() != want {
+		t.Errorf("Key() = %q, want %q", full.Key(), want)
+	}
+	if full.Other(alice) != bob || full.Other(bob) != alice {
+		t.Error("Other returned the wrong participant")
+	}
+
+	back, err := FromKey(full.Key())
+	if err != nil || back != full {
+		t.Errorf("FromKey round trip failed: %+v, %v", back, err)
+	}
+}
+
+func TestParseConversation(t *testing.T) {
+	raw := "conv:" + group.String()
+	r, err := Parse(raw, alice)
+	if err != nil {
+		t.Fatalf("Parse(%q): %v", raw, err)
+	}
+	if r.Kind != Conversation || r.ConversationID != group {
+		t.Errorf("Parse(%q) = %+v", raw, r)
+	}
+	if r.Key() != raw || r.String() != raw {
+		t.Errorf("unexpected key %q / id %q", r.Key(), r.String())
+	}
+	if back, err := FromKey(r.Key()); err != nil || back != r {
+		t.Errorf("FromKey round trip failed: %+v, %v", back, err)
+	}
+}
+
+func TestParseRejects(t *testing.T) {
+	cases := []string{
+		"",
+		"general",
+		"group:not-a-uuid",
+		"dm:" + alice.String(), // a DM with yourself
+		"dm:" + bob.String() + ":" + carol.String(), // someone else's DM
+		"dm:" + alice.String() + ":" + bob.String() + ":x",
+		"channel:" + group.String(),
+		"conv:not-a-uuid",
+	}
+	for _, raw := range cases {
+		if _, err := Parse(raw, alice); err == nil {
+			t.Errorf("Parse(%q) should fail", raw)
+		}
+	}
+}
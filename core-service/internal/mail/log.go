@@ -0,0 +1,55 @@
+package mail
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// LogMailer is the development mailer: it logs the recipient and subject of
+// every message and, when an outbox directory is set, writes the full
+// message there as an .eml file instead of delivering it. Bodies carry
+// single-use links, so they are never logged.
+type LogMailer struct {
+	log    *zap.Logger
+	outbox string
+}
+
+func NewLogMailer(log *zap.Logger, outbox string) (*LogMailer, error) {
+	if outbox != "" {
+		if err := os.MkdirAll(outbox, 0o755); err != nil {
+			return nil, err
+		}
+	}
+	return &LogMailer{log: log, outbox: outbox}, nil
+}
+
+func (m *LogMailer) Send(ctx context.Context, msg Message) error {
+	m.log.Info(
+		"email sent to log mailer",
+		zap.String("to", msg.To),
+		zap.String("subject", msg.Subject),
+	)
+
+	if m.outbox == "" {
+		return nil
+	}
+
+	name := fmt.Sprintf("%d-%s.eml", time.Now().UnixNano(), sanitize(msg.To))
+	return os.WriteFile(filepath.Join(m.outbox, name), formatMessage("core-service@localhost", msg), 0o644)
+}
+
+func sanitize(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '.', r == '-':
+			return r
+		}
+		return '_'
+	}, s)
+}
@@ -0,0 +1,54 @@
+package mail
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestLogMailer_WritesOutbox(t *testing.T) {
+	core, recorded := observer.New(zapcore.InfoLevel)
+	dir := t.TempDir()
+
+	m, err := NewLogMailer(zap.New(core), dir)
+	if err != nil {
+		t.Fatalf("NewLogMailer failed: %v", err)
+	}
+
+	err = m.Send(context.Background(), Message{
+		To:      "student@example.com",
+		Subject: "Reset your password",
+		Body:    "line one\nline two",
+	})
+	if err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+
+	if recorded.Len() != 1 {
+		t.Fatalf("expected one log entry, got %d", recorded.Len())
+	}
+	if _, ok := recorded.All()[0].ContextMap()["body"]; ok {
+		t.Errorf("message body was logged")
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("expected one file in outbox, got %d (%v)", len(entries), err)
+	}
+
+	data, err := os.ReadFile(dir + "/" + entries[0].Name())
+	if err != nil {
+		t.Fatalf("read outbox: %v", err)
+	}
+	if !strings.Contains(string(data), "Subject: Reset your password\r\n") {
+		t.Errorf("missing subject header in %q", data)
+	}
+	if !strings.Contains(string(data), "line one\r\nline two") {
+		t.Errorf("body not CRLF normalised in %q", data)
+	}
+}
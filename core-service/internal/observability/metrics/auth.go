@@ -5,6 +5,7 @@ import "go.opentelemetry.io/otel/metric"
 var (
 	AuthLoginSuccess metric.Int64Counter
 	AuthLoginFailure metric.Int64Counter
+	AuthRefreshReuse metric.Int64Counter
 )
 
 func InitAuthMetrics() error {
@@ -20,5 +21,12 @@ func InitAuthMetrics() error {
 	AuthLoginFailure, err = Meter.Int64Counter(
 		"auth.login.failure_total",
 	)
+	if err != nil {
+		return err
+	}
+
+	AuthRefreshReuse, err = Meter.Int64Counter(
+		"auth.refresh.reuse_total",
+	)
 	return err
 }
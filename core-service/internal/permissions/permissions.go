@@ -0,0 +1,101 @@
+// Package permissions is the group authorization model: every member has
+// one role, and each role grants a fixed set of named permissions.
+package permissions
+
+type Role string
+
+const (
+	Owner     Role = "owner"
+	Admin     Role = "admin"
+	Moderator Role = "moderator"
+	Member    Role = "member"
+	Viewer    Role = "viewer"
+)
+
+type Permission string
+
+const (
+	GroupUpdate   Permission = "group.update"
+	MemberView    Permission = "member.view"
+	MemberApprove Permission = "member.approve"
+	MemberManage  Permission = "member.manage"
+	TaskView      Permission = "task.view"
+	TaskCreate    Permission = "task.create"
+	ChatRead      Permission = "chat.read"
+	ChatPost      Permission = "chat.post"
+	ChatModerate  Permission = "chat.moderate"
+	ChatPin       Permission = "chat.pin"
+	ChatAnnounce  Permission = "chat.announce"
+)
+
+// rank orders roles; a member can only manage members ranked below them.
+var rank = map[Role]int{
+	Viewer:    1,
+	Member:    2,
+	Moderator: 3,
+	Admin:     4,
+	Owner:     5,
+}
+
+var grants = map[Role][]Permission{
+	Viewer: {MemberView, TaskView, ChatRead},
+	Member: {MemberView, TaskView, TaskCreate, ChatRead, ChatPost},
+	Moderator: {
+		MemberView, MemberApprove,
+		TaskView, TaskCreate,
+		ChatRead, ChatPost, ChatModerate,
+	},
+	Admin: {
+		GroupUpdate,
+		MemberView, MemberApprove, MemberManage,
+		TaskView, TaskCreate,
+		ChatRead, ChatPost, ChatModerate, ChatPin, ChatAnnounce,
+	},
+	Owner: {
+		GroupUpdate,
+		MemberView, MemberApprove, MemberManage,
+		TaskView, TaskCreate,
+		ChatRead, ChatPost, ChatModerate, ChatPin, ChatAnnounce,
+	},
+}
+
+var lookup = func() map[Role]map[Permission]bool {
+	m := make(map[Role]map[Permission]bool, len(grants))
+	for role, perms := range grants {
+		m[role] = make(map[Permission]bool, len(perms))
+		for _, p := range perms {
+			m[role][p] = true
+		}
+	}
+	return m
+}()
+
+// Valid reports whether r is a known role.
+func Valid(r string) bool {
+	_, ok := rank[Role(r)]
+	return ok
+}
+
+// Has reports whether the role grants the permission. Unknown roles grant
+// nothing.
+func Has(role string, p Permission) bool {
+	return lookup[Role(role)][p]
+}
+
+// Outranks reports whether actor sits strictly above target.
+func Outranks(actor, target string) bool {
+	a, ok := rank[Role(actor)]
+	if !ok {
+		return false
+	}
+	return a > rank[Role(target)]
+}
+
+// CanAssign reports whether actor may move a member currently holding
+// current to the role next. Ownership cannot be handed out this way.
+func CanAssign(actor, current, next string) bool {
+	if !Valid(next) || Role(next) == Owner {
+		return false
+	}
+	return Has(actor, MemberManage) && Outranks(actor, current) && Outranks(actor, next)
+}
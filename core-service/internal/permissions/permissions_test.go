@@ -0,0 +1,63 @@
+package permissions
+
+import "testing"
+
+func TestHas(t *testing.T) {
+	cases := []struct {
+		role string
+		perm Permission
+		want bool
+	}{
+		{"viewer", ChatRead, true},
+		{"viewer", ChatPost, false},
+		{"member", TaskCreate, true},
+		{"member", MemberApprove, false},
+		{"moderator", MemberApprove, true},
+		{"moderator", MemberManage, false},
+		{"admin", GroupUpdate, true},
+		{"admin", ChatPin, true},
+		{"owner", ChatAnnounce, true},
+		{"moderator", ChatPin, false},
+		{"member", ChatAnnounce, false},
+		{"owner", MemberManage, true},
+		{"", TaskView, false},
+		{"superuser", TaskView, false},
+	}
+
+	for _, tc := range cases {
+		if got := Has(tc.role, tc.perm); got != tc.want {
+			t.Errorf("Has(%q, %q) = %v, want %v", tc.role, tc.perm, got, tc.want)
+		}
+	}
+}
+
+func TestCanAssign(t *testing.T) {
+	cases := []struct {
+		actor, current, next string
+		want                 bool
+	}{
+		{"owner", "member", "admin", true},
+		{"owner", "admin", "member", true},
+		{"owner", "member", "owner", false},
+		{"admin", "member", "moderator", true},
+		{"admin", "member", "admin", false},
+		{"admin", "admin", "member", false},
+		{"admin", "owner", "member", false},
+		{"moderator", "viewer", "member", false},
+		{"admin", "member", "root", false},
+	}
+
+	for _, tc := range cases {
+		if got := CanAssign(tc.actor, tc.current, tc.next); got != tc.want {
+			t.Errorf("CanAssign(%q, %q, %q) = %v, want %v", tc.actor, tc.current, tc.next, got, tc.want)
+		}
+	}
+}
+
+func TestEveryRoleHasGrants(t *testing.T) {
+	for role := range rank {
+		if len(grants[role]) == 0 {
+			t.Errorf("role %s grants nothing", role)
+		}
+	}
+}
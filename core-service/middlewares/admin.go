@@ -0,0 +1,21 @@
+package middlewares
+
+import (
+	"net/http"
+
+	"core-service/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequireAdmin must run after JWTAuthMiddleware.
+func RequireAdmin() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user := c.MustGet("user").(models.User)
+		if !user.IsAdmin {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "admin access required"})
+			return
+		}
+		c.Next()
+	}
+}
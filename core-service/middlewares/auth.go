@@ -1,7 +1,9 @@
 package middlewares
 
 import (
+	"errors"
 	"net/http"
+	"time"
 
 	"core-service/config"
 	"core-service/internal/observability/logging"
@@ -9,6 +11,7 @@ import (
 	"core-service/utils"
 
 	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/codes"
@@ -17,8 +20,31 @@ import (
 
 var jwtTracer = otel.Tracer("middlewares.auth")
 
+// unverifiedAllowed lists the routes an account with an unverified email
+// may use when REQUIRE_EMAIL_VERIFICATION is on.
+var unverifiedAllowed = map[string]bool{
+	"GET /user/me":                        true,
+	"PUT /user/me":                        true,
+	"DELETE /user/me":                     true,
+	"POST /auth/logout":                   true,
+	"POST /auth/email/resend":             true,
+	"GET /user/me/sessions":               true,
+	"DELETE /user/me/sessions":            true,
+	"DELETE /user/me/sessions/:sessionId": true,
+}
+
+// sessionTouchInterval bounds how often last_seen_at is written for a session.
+const sessionTouchInterval = 5 * time.Minute
+
+// JWTAuthMiddleware authenticates the session cookie, or a personal access
+// token sent as "Authorization: Bearer".
 func JWTAuthMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
+		if plain, ok := bearerToken(c); ok {
+			authenticateAccessToken(c, plain)
+			return
+		}
+
 		ctx := c.Request.Context()
 		log := logging.Logger(ctx)
 
@@ -34,6 +60,13 @@ func JWTAuthMiddleware() gin.HandlerFunc {
 		}
 
 		claims, err := utils.ParseToken(token)
+		if errors.Is(err, jwt.ErrTokenExpired) {
+			// Expired access tokens are routine; the client is expected to
+			// call /auth/refresh and retry.
+			span.AddEvent("expired_token")
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "token expired"})
+			return
+		}
 		if err != nil {
 			span.AddEvent("invalid_token")
 			log.Warn("invalid auth token", zap.Error(err))
@@ -45,6 +78,35 @@ func JWTAuthMiddleware() gin.HandlerFunc {
 			attribute.String("user.id", claims.UserID.String()),
 		)
 
+		sessionID, err := claims.SessionID()
+		if err != nil {
+			span.AddEvent("missing_session")
+			log.Warn("token without session", zap.Error(err))
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+			return
+		}
+
+		var session models.Session
+		if err := config.DB.WithContext(ctx).
+			First(&session, "id = ? AND user_id = ?", sessionID, claims.UserID).Error; err != nil || !session.Active(time.Now()) {
+
+			span.AddEvent("session_revoked")
+			log.Warn(
+				"session revoked or expired",
+				zap.String("user_id", claims.UserID.String()),
+				zap.String("session_id", sessionID.String()),
+			)
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "session expired"})
+			return
+		}
+
+		if time.Since(session.LastSeenAt) > sessionTouchInterval {
+			if err := config.DB.WithContext(ctx).Model(&session).
+				UpdateColumn("last_seen_at", time.Now()).Error; err != nil {
+				log.Warn("failed to touch session", zap.Error(err))
+			}
+		}
+
 		var user models.User
 		if err := config.DB.WithContext(ctx).First(&user, claims.UserID).Error; err != nil {
 			span.RecordError(err)
@@ -58,10 +120,19 @@ func JWTAuthMiddleware() gin.HandlerFunc {
 			return
 		}
 
+		if user.VerifiedAt == nil && config.RequireEmailVerification() &&
+			!unverifiedAllowed[c.Request.Method+" "+c.FullPath()] {
+
+			span.AddEvent("email_not_verified")
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "email not verified"})
+			return
+		}
+
 		span.SetStatus(codes.Ok, "authenticated")
 
 		c.Set("user", user)
 		c.Set("user_id", claims.UserID)
+		c.Set("session_id", sessionID)
 
 		c.Next()
 	}
@@ -0,0 +1,82 @@
+package middlewares
+
+import (
+	"errors"
+	"net/http"
+
+	"core-service/config"
+	"core-service/internal/observability/logging"
+	"core-service/internal/permissions"
+	"core-service/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+var groupTracer = otel.Tracer("middlewares.group")
+
+// RequireGroupPermission lets the request through only when the caller is
+// a joined member of the :groupId group whose role grants perm. It must run
+// after JWTAuthMiddleware; on success the membership is available to the
+// handler as "group_member".
+func RequireGroupPermission(perm permissions.Permission) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+		log := logging.Logger(ctx)
+
+		ctx, span := groupTracer.Start(ctx, "auth.group_permission")
+		defer span.End()
+
+		userID := c.MustGet("user_id").(uuid.UUID)
+
+		groupID, err := uuid.Parse(c.Param("groupId"))
+		if err != nil {
+			span.AddEvent("invalid_group_id")
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Invalid group ID"})
+			return
+		}
+
+		span.SetAttributes(
+			attribute.String("group.id", groupID.String()),
+			attribute.String("user.id", userID.String()),
+			attribute.String("permission", string(perm)),
+		)
+
+		var member models.GroupMember
+		err = config.DB.WithContext(ctx).First(
+			&member,
+			"group_id = ? AND user_id = ? AND status = ?",
+			groupID, userID, "joined",
+		).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "membership lookup failed")
+			log.Error("membership lookup failed", zap.Error(err))
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+			return
+		}
+
+		if err != nil || !permissions.Has(member.Role, perm) {
+			span.AddEvent("permission_denied")
+			log.Warn("group permission denied",
+				zap.String("group_id", groupID.String()),
+				zap.String("user_id", userID.String()),
+				zap.String("role", member.Role),
+				zap.String("permission", string(perm)),
+			)
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "You do not have permission to do this in this group"})
+			return
+		}
+
+		span.SetAttributes(attribute.String("group.role", member.Role))
+		span.SetStatus(codes.Ok, "permitted")
+
+		c.Set("group_member", member)
+		c.Next()
+	}
+}
@@ -1,15 +1,25 @@
 package models
 
 import (
+	"time"
+
 	"github.com/google/uuid"
 )
 
 type User struct {
-	ID       uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"id"`
-	Username string    `gorm:"unique" json:"username"`
-	Email    string    `gorm:"unique" json:"email"`
-	Password string    `json:"-"`
-	Avatar   string    `json:"avatar,omitempty"`
+	ID         uuid.UUID  `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"id"`
+	Username   string     `gorm:"unique" json:"username"`
+	Email      string     `gorm:"unique" json:"email"`
+	Password   string     `json:"-"`
+	Avatar     string     `json:"avatar,omitempty"`
+	VerifiedAt *time.Time `json:"verified_at"`
+	IsAdmin    bool       `gorm:"not null;default:false" json:"is_admin"`
+
+	// TOTPSecret is set at enrollment; two-factor login is only enforced
+	// once TOTPEnabledAt is set by a confirmed code.
+	TOTPSecret    string     `json:"-"`
+	TOTPEnabledAt *time.Time `json:"totp_enabled_at"`
+	TOTPLastStep  int64      `json:"-"`
 }
 
 type RegisterInput struct {
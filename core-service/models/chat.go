@@ -16,10 +16,88 @@ type ChatMessage struct {
 	User   User      `gorm:"foreignKey:UserID"`
 
 	Text string `gorm:"type:text"`
+	// Kind is MessageText for ordinary messages.
+	Kind string `gorm:"type:varchar(16);not null;default:'text'"`
 
 	Timestamp time.Time `gorm:"index:idx_room_timestamp,priority:2"`
 
+	// ParentID is set on thread replies. Threads are one level deep: it
+	// always points at a top-level message.
+	ParentID *uuid.UUID `gorm:"type:uuid;index"`
+
+	EditedAt *time.Time
+	// Deleted messages stay in place as tombstones so history cursors
+	// keep working; their text and attachments are no longer served.
+	DeletedAt *time.Time
+	DeletedBy *uuid.UUID `gorm:"type:uuid"`
+
+	// PinnedAt is set while the message is pinned to its room.
+	PinnedAt *time.Time
+	PinnedBy *uuid.UUID `gorm:"type:uuid"`
+
 	Attachments []Attachment `gorm:"foreignKey:ChatMessageID;constraint:OnDelete:CASCADE;"`
+
+	// SearchVector indexes the text and attachment file names for full-text
+	// search. It is maintained in SQL and never read into Go.
+	SearchVector string `gorm:"type:tsvector;index:idx_chat_messages_search,type:gin;->:false;<-:false" json:"-"`
+}
+
+// Message kinds. Announcements are posted by group admins, highlighted
+// and cannot be replied to; polls have a Poll holding their options.
+const (
+	MessageText         = "text"
+	MessageAnnouncement = "announcement"
+	MessagePoll         = "poll"
+)
+
+// SearchConfig is the text search configuration used for chat messages.
+const SearchConfig = "english"
+
+// RefreshSearchVectors rebuilds SearchVector for the chat_messages rows
+// (aliased m) matching where.
+const RefreshSearchVectors = `UPDATE chat_messages m SET search_vector =
+	setweight(to_tsvector('` + SearchConfig + `', coalesce(m.text, '')), 'A') ||
+	setweight(to_tsvector('` + SearchConfig + `', coalesce(
+		(SELECT string_agg(a.file_name, ' ') FROM attachments a WHERE a.chat_message_id = m.id), '')), 'B')
+	WHERE `
+
+// ChatMessageEdit keeps the text a message had before each edit.
+type ChatMessageEdit struct {
+	ID            uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
+	ChatMessageID uuid.UUID `gorm:"type:uuid;not null;index" json:"message_id"`
+	EditorID      uuid.UUID `gorm:"type:uuid;not null" json:"editor_id"`
+	PreviousText  string    `gorm:"type:text" json:"previous_text"`
+	EditedAt      time.Time `json:"edited_at"`
+}
+
+// ChatReadCursor is the newest message a user has read in a room. It only
+// moves forward, ordered like history by (timestamp, id).
+type ChatReadCursor struct {
+	UserID           uuid.UUID `gorm:"type:uuid;primaryKey" json:"user_id"`
+	RoomID           string    `gorm:"type:varchar(255);primaryKey;index" json:"-"`
+	MessageID        uuid.UUID `gorm:"type:uuid;not null" json:"message_id"`
+	MessageTimestamp time.Time `gorm:"not null" json:"-"`
+	ReadAt           time.Time `json:"read_at"`
+}
+
+// ChatMention records that a message mentioned a user, by name or through
+// @here or @admins.
+type ChatMention struct {
+	MessageID uuid.UUID `gorm:"type:uuid;primaryKey"`
+	UserID    uuid.UUID `gorm:"type:uuid;primaryKey;index"`
+	RoomID    string    `gorm:"type:varchar(255);not null;index"`
+	Kind      string    `gorm:"type:varchar(16);not null"`
+	CreatedAt time.Time
+}
+
+// MessageReaction is one user's emoji on a message. A user can react with
+// several emoji, but with each one only once.
+type MessageReaction struct {
+	ID        uuid.UUID `gorm:"type:uuid;primaryKey"`
+	MessageID uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_reaction_unique,priority:1"`
+	UserID    uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_reaction_unique,priority:2"`
+	Emoji     string    `gorm:"type:varchar(32);not null;uniqueIndex:idx_reaction_unique,priority:3"`
+	CreatedAt time.Time
 }
 
 type Attachment struct {
@@ -46,6 +124,34 @@ func (msg *ChatMessage) BeforeCreate(tx *gorm.DB) (err error) {
 	return
 }
 
+// AfterCreate indexes the message once its attachments are saved.
+func (msg *ChatMessage) AfterCreate(tx *gorm.DB) (err error) {
+	return tx.Exec(RefreshSearchVectors+"m.id = ?", msg.ID).Error
+}
+
+// AfterUpdate re-indexes an edited message. Bulk updates without a loaded
+// message, such as deletions, leave the index alone.
+func (msg *ChatMessage) AfterUpdate(tx *gorm.DB) (err error) {
+	if msg.ID == uuid.Nil {
+		return nil
+	}
+	return tx.Exec(RefreshSearchVectors+"m.id = ?", msg.ID).Error
+}
+
+func (e *ChatMessageEdit) BeforeCreate(tx *gorm.DB) (err error) {
+	if e.ID == uuid.Nil {
+		e.ID = uuid.New()
+	}
+	return
+}
+
+func (r *MessageReaction) BeforeCreate(tx *gorm.DB) (err error) {
+	if r.ID == uuid.Nil {
+		r.ID = uuid.New()
+	}
+	return
+}
+
 func (att *Attachment) BeforeCreate(tx *gorm.DB) (err error) {
 	if att.ID == uuid.Nil {
 		att.ID = uuid.New()
@@ -0,0 +1,41 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	ConversationDirect = "direct"
+	ConversationGroup  = "group"
+)
+
+// Conversation is a direct-message thread between two users or a small
+// group of them. Its messages are chat messages stored under RoomKey:
+// "dm:<userA>:<userB>" for direct conversations, "conv:<id>" otherwise.
+type Conversation struct {
+	ID        uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
+	Kind      string    `gorm:"type:varchar(16);not null" json:"kind"`
+	Title     string    `json:"title,omitempty"`
+	RoomKey   string    `gorm:"type:varchar(255);not null;uniqueIndex" json:"room"`
+	CreatedBy uuid.UUID `gorm:"type:uuid;not null" json:"created_by"`
+	CreatedAt time.Time `json:"created_at"`
+
+	Participants []ConversationParticipant `gorm:"foreignKey:ConversationID;constraint:OnDelete:CASCADE;" json:"participants"`
+}
+
+type ConversationParticipant struct {
+	ConversationID uuid.UUID `gorm:"type:uuid;primaryKey" json:"-"`
+	UserID         uuid.UUID `gorm:"type:uuid;primaryKey;index" json:"user_id"`
+	User           User      `gorm:"foreignKey:UserID" json:"-"`
+	JoinedAt       time.Time `json:"joined_at"`
+}
+
+func (c *Conversation) BeforeCreate(tx *gorm.DB) (err error) {
+	if c.ID == uuid.Nil {
+		c.ID = uuid.New()
+	}
+	return
+}
@@ -0,0 +1,37 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	NotificationMention      = "mention"
+	NotificationAnnouncement = "announcement"
+)
+
+// Notification is kept for a user until they read it, whether or not they
+// were connected when it was raised.
+type Notification struct {
+	ID        uuid.UUID  `gorm:"type:uuid;primaryKey" json:"id"`
+	UserID    uuid.UUID  `gorm:"type:uuid;not null;index:idx_notification_user,priority:1" json:"-"`
+	Kind      string     `gorm:"type:varchar(32);not null" json:"kind"`
+	Room      string     `gorm:"type:varchar(255)" json:"room,omitempty"`
+	MessageID *uuid.UUID `gorm:"type:uuid" json:"message_id,omitempty"`
+	ActorID   *uuid.UUID `gorm:"type:uuid" json:"actor_id,omitempty"`
+	Body      string     `gorm:"type:text" json:"body"`
+	ReadAt    *time.Time `json:"read_at"`
+	CreatedAt time.Time  `gorm:"index:idx_notification_user,priority:2" json:"created_at"`
+}
+
+func (n *Notification) BeforeCreate(tx *gorm.DB) (err error) {
+	if n.ID == uuid.Nil {
+		n.ID = uuid.New()
+	}
+	if n.CreatedAt.IsZero() {
+		n.CreatedAt = time.Now()
+	}
+	return
+}
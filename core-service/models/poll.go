@@ -0,0 +1,52 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Poll is the ballot of a chat message of kind MessagePoll. The message
+// text holds the question.
+type Poll struct {
+	ID        uuid.UUID  `gorm:"type:uuid;primaryKey"`
+	MessageID uuid.UUID  `gorm:"type:uuid;not null;uniqueIndex"`
+	Multiple  bool       `gorm:"not null;default:false"`
+	Anonymous bool       `gorm:"not null;default:false"`
+	ClosesAt  *time.Time `gorm:"index"`
+	ClosedAt  *time.Time
+	CreatedBy uuid.UUID `gorm:"type:uuid;not null"`
+	CreatedAt time.Time
+	Options   []PollOption `gorm:"foreignKey:PollID;constraint:OnDelete:CASCADE;"`
+}
+
+type PollOption struct {
+	ID       uuid.UUID `gorm:"type:uuid;primaryKey"`
+	PollID   uuid.UUID `gorm:"type:uuid;not null;index"`
+	Position int       `gorm:"not null"`
+	Text     string    `gorm:"type:varchar(255);not null"`
+}
+
+// PollVote is one user's choice of one option. In multiple-choice polls a
+// user has a row per chosen option.
+type PollVote struct {
+	PollID    uuid.UUID `gorm:"type:uuid;not null;index:idx_poll_vote_user,priority:1"`
+	OptionID  uuid.UUID `gorm:"type:uuid;primaryKey"`
+	UserID    uuid.UUID `gorm:"type:uuid;primaryKey;index:idx_poll_vote_user,priority:2"`
+	CreatedAt time.Time
+}
+
+func (p *Poll) BeforeCreate(tx *gorm.DB) (err error) {
+	if p.ID == uuid.Nil {
+		p.ID = uuid.New()
+	}
+	return
+}
+
+func (o *PollOption) BeforeCreate(tx *gorm.DB) (err error) {
+	if o.ID == uuid.Nil {
+		o.ID = uuid.New()
+	}
+	return
+}
@@ -0,0 +1,28 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// RefreshToken is one link in a session's rotation chain. All tokens of a
+// session form a family: presenting a token that was already used revokes
+// the session and with it every token of the family.
+type RefreshToken struct {
+	ID           uuid.UUID `gorm:"type:uuid;primaryKey"`
+	SessionID    uuid.UUID `gorm:"type:uuid;not null;index"`
+	TokenHash    string    `gorm:"type:char(64);not null;uniqueIndex"`
+	CreatedAt    time.Time
+	ExpiresAt    time.Time
+	UsedAt       *time.Time
+	ReplacedByID *uuid.UUID `gorm:"type:uuid"`
+}
+
+func (t *RefreshToken) BeforeCreate(tx *gorm.DB) (err error) {
+	if t.ID == uuid.Nil {
+		t.ID = uuid.New()
+	}
+	return
+}
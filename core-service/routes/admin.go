@@ -0,0 +1,15 @@
+package routes
+
+import (
+	"core-service/controllers"
+	"core-service/middlewares"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterAdminRoutes(router *gin.Engine) {
+	admin := router.Group("/admin")
+	admin.Use(middlewares.JWTAuthMiddleware(), middlewares.RequireAdmin())
+
+	admin.POST("/users/:userId/unlock", controllers.UnlockUser)
+}
@@ -8,16 +8,27 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-func RegisterAuthRoutes(r *gin.Engine) {
+func RegisterAuthRoutes(r *gin.Engine, oidcHandler *controllers.OIDCHandler) {
+	r.GET("/.well-known/jwks.json", controllers.JWKS)
 
 	auth := r.Group("/auth")
 	{
 		auth.POST("/register", controllers.Register)
 		auth.POST("/login", controllers.Login)
+		auth.POST("/login/mfa", controllers.VerifyMFA)
+		auth.POST("/refresh", controllers.RefreshSession)
+		auth.POST("/password/forgot", controllers.ForgotPassword)
+		auth.POST("/password/reset", controllers.ResetPassword)
+		auth.POST("/email/verify", controllers.VerifyEmail)
+
+		auth.GET("/oidc", oidcHandler.ListProviders)
+		auth.GET("/oidc/:provider/login", oidcHandler.Login)
+		auth.GET("/oidc/:provider/callback", oidcHandler.Callback)
 
 		auth.Use(middlewares.JWTAuthMiddleware())
 
 		auth.POST("/logout", controllers.Logout)
+		auth.POST("/email/resend", controllers.ResendVerificationEmail)
 	}
 
 }
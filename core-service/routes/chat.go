@@ -2,6 +2,7 @@ package routes
 
 import (
 	"core-service/controllers"
+	"core-service/internal/permissions"
 	"core-service/middlewares"
 
 	"github.com/gin-gonic/gin"
@@ -12,4 +13,16 @@ func RegisterChatRoutes(router *gin.Engine, chatHandler *controllers.ChatHandler
 	chat.Use(middlewares.JWTAuthMiddleware())
 
 	chat.GET("", chatHandler.HandleConnection)
+
+	groups := router.Group("/groups")
+	groups.Use(middlewares.JWTAuthMiddleware())
+
+	canRead := middlewares.RequireGroupPermission(permissions.ChatRead)
+
+	groups.GET("/:groupId/messages", canRead, chatHandler.ListMessages)
+	groups.GET("/:groupId/messages/:messageId/edits", canRead, chatHandler.ListMessageEdits)
+	groups.GET("/:groupId/messages/:messageId/replies", canRead, chatHandler.ListReplies)
+	groups.GET("/:groupId/presence", canRead, chatHandler.GroupPresence)
+	groups.GET("/:groupId/receipts", canRead, chatHandler.ListReadCursors)
+	groups.GET("/:groupId/pins", canRead, chatHandler.ListPins)
 }
@@ -2,6 +2,7 @@ package routes
 
 import (
 	"core-service/controllers"
+	"core-service/internal/permissions"
 	"core-service/middlewares"
 
 	"github.com/gin-gonic/gin"
@@ -13,18 +14,20 @@ func RegisterGroupRoutes(router *gin.Engine) {
 
 	group.Use(middlewares.JWTAuthMiddleware())
 
+	can := middlewares.RequireGroupPermission
+
 	group.POST("", controllers.CreateGroup)
 
 	group.POST("/:groupId/join", controllers.JoinGroup)
-	group.PUT(":groupId/requests/:userId", controllers.AcceptMemberRequest)
-	group.DELETE(":groupId/requests/:userId", controllers.RejectMemberRequest)
+	group.PUT(":groupId/requests/:userId", can(permissions.MemberApprove), controllers.AcceptMemberRequest)
+	group.DELETE(":groupId/requests/:userId", can(permissions.MemberApprove), controllers.RejectMemberRequest)
 	group.GET("/:groupId", controllers.GetGroupDetails)
-	group.GET("/:groupId/members", controllers.GetMembers)
-	group.PUT("/:groupId", controllers.UpdateGroupDetails)
+	group.GET("/:groupId/members", can(permissions.MemberView), controllers.GetMembers)
+	group.PUT("/:groupId", can(permissions.GroupUpdate), controllers.UpdateGroupDetails)
 	group.GET("/:groupId/user/status", controllers.FetchMembershipStatus)
-	group.GET("/:groupId/requests", controllers.GetMemberRequests)
-	group.PUT("/:groupId/members/:memberid", controllers.UpdateGroupMember)
-	group.POST("/:groupId/tasks", controllers.CreateTask)
-	group.GET("/:groupId/tasks", controllers.ListTasks)
+	group.GET("/:groupId/requests", can(permissions.MemberApprove), controllers.GetMemberRequests)
+	group.PUT("/:groupId/members/:memberid", can(permissions.MemberManage), controllers.UpdateGroupMember)
+	group.POST("/:groupId/tasks", can(permissions.TaskCreate), controllers.CreateTask)
+	group.GET("/:groupId/tasks", can(permissions.TaskView), controllers.ListTasks)
 
 }
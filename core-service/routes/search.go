@@ -0,0 +1,15 @@
+package routes
+
+import (
+	"core-service/controllers"
+	"core-service/middlewares"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterSearchRoutes(router *gin.Engine) {
+	search := router.Group("/search")
+	search.Use(middlewares.JWTAuthMiddleware())
+
+	search.GET("/messages", controllers.SearchMessages)
+}
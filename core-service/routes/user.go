@@ -18,10 +18,31 @@ func RegisterUserRoutes(router *gin.Engine) {
 		user.PUT("/me/password", controllers.ChangePassword)
 		user.DELETE("/me", controllers.DeleteUser)
 
+		user.GET("/me/sessions", controllers.ListSessions)
+		user.DELETE("/me/sessions", controllers.RevokeOtherSessions)
+		user.DELETE("/me/sessions/:sessionId", controllers.RevokeSession)
+
+		user.POST("/me/2fa/enroll", controllers.EnrollTOTP)
+		user.POST("/me/2fa/confirm", controllers.ConfirmTOTP)
+		user.POST("/me/2fa/disable", controllers.DisableTOTP)
+		user.POST("/me/2fa/recovery-codes", controllers.RegenerateRecoveryCodes)
+
+		user.GET("/me/identities", controllers.ListIdentities)
+		user.DELETE("/me/identities/:identityId", controllers.UnlinkIdentity)
+
+		user.GET("/me/tokens", controllers.ListAccessTokens)
+		user.POST("/me/tokens", controllers.CreateAccessToken)
+		user.DELETE("/me/tokens/:tokenId", controllers.RevokeAccessToken)
+
 		user.GET("/:userId/profile", controllers.GetUserProfile)
 		user.GET("/users/:userId/mutual-groups", controllers.GetMutualGroups)
 		user.GET("/groups", controllers.GetUserGroups)
 		user.GET("/tasks", controllers.GetUserTasks)
+		user.GET("/rooms/unread", controllers.GetUnreadCounts)
+		user.GET("/conversations", controllers.ListConversations)
+		user.POST("/conversations", controllers.CreateConversation)
+		user.GET("/notifications", controllers.ListNotifications)
+		user.POST("/notifications/read", controllers.MarkNotificationsRead)
 
 	}
 
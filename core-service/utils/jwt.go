@@ -2,36 +2,53 @@ package utils
 
 import (
 	"errors"
-	"os"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
 )
 
+// Claims identifies the user and, through the registered jti claim, the
+// server-side session the token was issued for.
 type Claims struct {
 	UserID uuid.UUID `json:"user_id"`
 	jwt.RegisteredClaims
 }
 
-var JWT_SECRET = os.Getenv("JWT_SECRET")
+const (
+	// AccessTokenTTL is the lifetime of the signed token sent on every request.
+	AccessTokenTTL = 15 * time.Minute
+	// RefreshTokenTTL is how long an unused refresh token stays valid.
+	RefreshTokenTTL = 7 * 24 * time.Hour
+	// SessionMaxLifetime caps how far rotation can extend a session.
+	SessionMaxLifetime = 30 * 24 * time.Hour
 
-func GenerateToken(userID uuid.UUID) (string, error) {
+	// TokenIssuer is the iss claim other services verify our tokens against.
+	TokenIssuer = "core-service"
+)
+
+func GenerateToken(userID, sessionID uuid.UUID) (string, error) {
+	now := time.Now()
 	claims := &Claims{
 		UserID: userID,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour * 72)),
+			ID:        sessionID.String(),
+			Issuer:    TokenIssuer,
+			Subject:   userID.String(),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(AccessTokenTTL)),
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(JWT_SECRET))
+	return keys.Sign(claims)
 }
 
 func ParseToken(tokenStr string) (*Claims, error) {
-	token, err := jwt.ParseWithClaims(tokenStr, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-		return []byte(JWT_SECRET), nil
-	})
+	token, err := jwt.ParseWithClaims(tokenStr, &Claims{}, keys.Keyfunc,
+		jwt.WithValidMethods([]string{jwt.SigningMethodRS256.Alg(), jwt.SigningMethodEdDSA.Alg()}),
+		jwt.WithIssuer(TokenIssuer),
+		jwt.WithExpirationRequired(),
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -42,3 +59,11 @@ func ParseToken(tokenStr string) (*Claims, error) {
 	}
 	return claims, nil
 }
+
+// SessionID returns the session the token was issued for.
+func (c *Claims) SessionID() (uuid.UUID, error) {
+	if c.ID == "" {
+		return uuid.Nil, errors.New("token has no session")
+	}
+	return uuid.Parse(c.ID)
+}
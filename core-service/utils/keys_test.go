@@ -0,0 +1,125 @@
+package utils
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+func writePEM(t *testing.T, dir, kid, blockType string, der []byte) {
+	t.Helper()
+	data := pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der})
+	if err := os.WriteFile(filepath.Join(dir, kid+".pem"), data, 0o600); err != nil {
+		t.Fatalf("write key: %v", err)
+	}
+}
+
+func newTestKeyDir(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate rsa: %v", err)
+	}
+	writePEM(t, dir, "2026-01", "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(rsaKey))
+
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("generate ed25519: %v", err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(edKey)
+	if err != nil {
+		t.Fatalf("marshal ed25519: %v", err)
+	}
+	writePEM(t, dir, "2026-02", "PRIVATE KEY", der)
+
+	return dir
+}
+
+func TestLoadKeySet_PicksLastPrivateKey(t *testing.T) {
+	ks, err := LoadKeySet(newTestKeyDir(t), "")
+	if err != nil {
+		t.Fatalf("LoadKeySet failed: %v", err)
+	}
+
+	if ks.ActiveKID() != "2026-02" {
+		t.Errorf("expected active kid 2026-02, got %s", ks.ActiveKID())
+	}
+
+	jwks := ks.JWKS()
+	if len(jwks.Keys) != 2 {
+		t.Fatalf("expected 2 keys in JWKS, got %d", len(jwks.Keys))
+	}
+	if jwks.Keys[0].Kty != "RSA" || jwks.Keys[1].Kty != "OKP" {
+		t.Errorf("unexpected key types %s, %s", jwks.Keys[0].Kty, jwks.Keys[1].Kty)
+	}
+}
+
+func TestTokenRoundTrip_AcrossRotation(t *testing.T) {
+	dir := newTestKeyDir(t)
+
+	old, err := LoadKeySet(dir, "2026-01")
+	if err != nil {
+		t.Fatalf("LoadKeySet failed: %v", err)
+	}
+	keys = old
+
+	userID, sessionID := uuid.New(), uuid.New()
+	token, err := GenerateToken(userID, sessionID)
+	if err != nil {
+		t.Fatalf("GenerateToken failed: %v", err)
+	}
+
+	rotated, err := LoadKeySet(dir, "2026-02")
+	if err != nil {
+		t.Fatalf("LoadKeySet failed: %v", err)
+	}
+	keys = rotated
+
+	claims, err := ParseToken(token)
+	if err != nil {
+		t.Fatalf("token signed before rotation rejected: %v", err)
+	}
+	if claims.UserID != userID {
+		t.Errorf("expected user %s, got %s", userID, claims.UserID)
+	}
+	if got, _ := claims.SessionID(); got != sessionID {
+		t.Errorf("expected session %s, got %s", sessionID, got)
+	}
+}
+
+func TestParseToken_RejectsHMAC(t *testing.T) {
+	ks, err := NewEphemeralKeySet()
+	if err != nil {
+		t.Fatalf("NewEphemeralKeySet failed: %v", err)
+	}
+	keys = ks
+
+	claims := &Claims{
+		UserID: uuid.New(),
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    TokenIssuer,
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+		},
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	token.Header["kid"] = ks.ActiveKID()
+	signed, err := token.SignedString([]byte("shared-secret"))
+	if err != nil {
+		t.Fatalf("sign failed: %v", err)
+	}
+
+	if _, err := ParseToken(signed); err == nil {
+		t.Fatal("expected HS256 token to be rejected")
+	}
+}
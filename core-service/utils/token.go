@@ -0,0 +1,38 @@
+package utils
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+)
+
+// GenerateOpaqueToken returns a random URL-safe token suitable for refresh,
+// reset and verification links. Only its hash should ever be persisted.
+func GenerateOpaqueToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// AccessTokenPrefix marks personal access tokens so they are recognisable
+// in a header and easy to find if leaked into a repository.
+const AccessTokenPrefix = "scp_"
+
+// GenerateAccessToken returns a new personal access token and the short
+// prefix shown to its owner.
+func GenerateAccessToken() (token, displayPrefix string, err error) {
+	body, err := GenerateOpaqueToken()
+	if err != nil {
+		return "", "", err
+	}
+	token = AccessTokenPrefix + body
+	return token, token[:len(AccessTokenPrefix)+8], nil
+}
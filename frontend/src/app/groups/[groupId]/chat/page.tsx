@@ -28,6 +28,43 @@ interface Message {
   text: string;
   attachments: Attachment[]; 
   timestamp: string;
+  edited_at?: string;
+  deleted?: boolean;
+  parent_id?: string;
+  reply_count?: number;
+  latest_reply?: { id: string; user: User; timestamp: string };
+  reactions?: Reaction[];
+  kind?: 'text' | 'announcement' | 'poll';
+  poll?: Poll;
+  pinned_at?: string;
+  // Output of a slash command; never stored.
+  notice?: boolean;
+}
+
+interface Poll {
+  id: string;
+  options: { id: string; text: string; votes: number; users?: string[] }[];
+  multiple: boolean;
+  anonymous: boolean;
+  closes_at?: string;
+  closed: boolean;
+  voters: number;
+}
+
+interface Reaction {
+  emoji: string;
+  count: number;
+  users: string[];
+}
+
+// Chat socket frames follow core-service/internal/chat/protocol/schema.json.
+const PROTOCOL_VERSION = 1
+
+interface Frame<T = any> {
+  v: number;
+  type: string;
+  id?: string;
+  payload: T;
 }
 
 // --- COMPONENT ---
@@ -40,25 +77,117 @@ export default function GroupChatPage({ params }: { params: { groupId: string }
   const [newMessage, setNewMessage] = useState('')
   const [selectedFile, setSelectedFile] = useState<File | null>(null)
   const [isUploading, setIsUploading] = useState(false)
+  const [typingUsers, setTypingUsers] = useState<Record<string, string>>({})
+  const [replyTo, setReplyTo] = useState<Message | null>(null)
 
   // Refs
   const ws = useRef<WebSocket | null>(null)
   const fileInputRef = useRef<HTMLInputElement>(null)
+  const lastTypingSent = useRef(0)
   const messagesEndRef = useRef<HTMLDivElement>(null)
 
+  const room = `group:${groupId}`
+
+  const sendFrame = (type: string, payload: object) => {
+    const frame: Frame = { v: PROTOCOL_VERSION, type, id: crypto.randomUUID(), payload }
+    ws.current?.send(JSON.stringify(frame))
+    return frame.id
+  }
+
+  const markRead = (messageId?: string) => {
+    if (messageId && document.visibilityState === 'visible') {
+      sendFrame('read', { room, message_id: messageId })
+    }
+  }
+
   useEffect(() => {
     ws.current = new WebSocket("ws://localhost:8080/chat")
 
     ws.current.onopen = () => {
       console.log('WebSocket connected')
-      const joinMsg = { type: 'join', room: groupId }
-      ws.current?.send(JSON.stringify(joinMsg))
+      sendFrame('join', { room })
     }
 
     ws.current.onmessage = (event) => {
-      const msg = JSON.parse(event.data) as Message
-      setMessages((prev) => [...prev, msg])
-      scrollToBottom()
+      const frame = JSON.parse(event.data) as Frame
+      const data = frame.payload
+      switch (frame.type) {
+        case 'error':
+          console.warn(`Chat error (${data.code}): ${data.message}`)
+          return
+        case 'ack':
+          return
+        case 'history':
+          setMessages((prev) => {
+            const known = new Set(prev.map((m) => m.id))
+            return [...(data.messages as Message[]).filter((m) => !known.has(m.id)), ...prev]
+          })
+          // The page sent on join (without an id) holds the newest messages.
+          if (frame.id === undefined) markRead(data.messages.at(-1)?.id)
+          scrollToBottom()
+          return
+        case 'message.updated':
+          setMessages((prev) => prev.map((m) => (m.id === data.message.id ? data.message : m)))
+          return
+        case 'message.deleted':
+          setMessages((prev) => prev.map((m) => (m.id === data.message_id ? { ...m, text: '', attachments: [], deleted: true } : m)))
+          return
+        case 'typing':
+          setTypingUsers((prev) => {
+            const next = { ...prev }
+            if (data.typing) next[data.user.id] = data.user.name
+            else delete next[data.user.id]
+            return next
+          })
+          return
+        case 'reaction.updated':
+          setMessages((prev) => prev.map((m) => {
+            if (m.id !== data.message_id) return m
+            const reactions = [...(m.reactions ?? [])]
+            const i = reactions.findIndex((r) => r.emoji === data.emoji)
+            const users = (i >= 0 ? reactions[i].users : []).filter((u) => u !== data.user_id)
+            if (data.added) users.push(data.user_id)
+            const updated = { emoji: data.emoji, count: data.count, users }
+            if (i < 0) reactions.push(updated)
+            else if (data.count > 0) reactions[i] = updated
+            else reactions.splice(i, 1)
+            return { ...m, reactions }
+          }))
+          return
+        case 'poll.updated':
+          setMessages((prev) => prev.map((m) => (m.id === data.message_id ? { ...m, poll: data.poll } : m)))
+          return
+        case 'pin.updated':
+          setMessages((prev) => prev.map((m) => (m.id === data.message_id
+            ? { ...m, pinned_at: data.pinned ? data.message?.pinned_at ?? new Date().toISOString() : undefined }
+            : m)))
+          return
+        case 'notice':
+          setMessages((prev) => [...prev, {
+            id: crypto.randomUUID(),
+            user: data.user,
+            text: data.text,
+            attachments: [],
+            timestamp: new Date().toISOString(),
+            notice: true,
+          }])
+          scrollToBottom()
+          return
+        case 'presence.changed':
+        case 'receipt':
+          return
+        case 'message':
+          // Replies stay in their thread; the parent's count arrives as message.updated.
+          if (!data.parent_id) setMessages((prev) => [...prev, data as Message])
+          setTypingUsers((prev) => {
+            const next = { ...prev }
+            delete next[data.user.id]
+            return next
+          })
+          markRead(data.id)
+          scrollToBottom()
+          return
+      }
     }
 
     ws.current.onclose = () => console.log('WebSocket disconnected')
@@ -129,18 +258,25 @@ return { file_id };
       }
     }
 
-    const messagePayload = {
-  type: 'broadcast',
-  room: groupId,
-  text: newMessage,
-  attachments: attachmentsPayload
-}
-
-    
-    ws.current.send(JSON.stringify(messagePayload))
+    if (replyTo) {
+      sendFrame('reply', {
+        room,
+        message_id: replyTo.id,
+        text: newMessage,
+        attachments: attachmentsPayload
+      })
+    } else {
+      sendFrame('broadcast', {
+        room,
+        text: newMessage,
+        attachments: attachmentsPayload
+      })
+    }
     
     setNewMessage('')
     setSelectedFile(null)
+    setReplyTo(null)
+    lastTypingSent.current = 0
   }
 
   return (
@@ -151,6 +287,13 @@ return { file_id };
       <div className="flex-1 bg-white border border-gray-200 rounded-xl shadow-sm p-6 overflow-y-auto space-y-6">
         {messages.map((msg) => {
           const isCurrentUser = msg.user.id === FAKE_CURRENT_USER.id
+          if (msg.notice) {
+            return (
+              <div key={msg.id} className="text-center text-xs text-gray-500 whitespace-pre-line">
+                {msg.text}
+              </div>
+            )
+          }
           return (
             <div key={msg.id} className={`flex gap-3 ${isCurrentUser ? 'justify-end' : 'justify-start'}`}>
               
@@ -170,8 +313,14 @@ return { file_id };
                       ? 'bg-amber-400 text-white rounded-br-none' 
                       : 'bg-gray-100 text-gray-800 rounded-bl-none'
                     }
+                    ${msg.kind === 'announcement' ? 'ring-2 ring-emerald-400' : ''}
                   `}
                 >
+                  {(msg.kind === 'announcement' || msg.pinned_at) && (
+                    <div className="text-xs font-semibold uppercase tracking-wide mb-1 text-emerald-600">
+                      {[msg.kind === 'announcement' && 'Announcement', msg.pinned_at && 'Pinned'].filter(Boolean).join(' · ')}
+                    </div>
+                  )}
                   {!isCurrentUser && (
                     <div className="font-semibold text-sm mb-1 text-emerald-500">{msg.user.name}</div>
                   )}
@@ -206,20 +355,100 @@ return { file_id };
 
                   {/* Text Content */}
                   {msg.text && <p>{msg.text}</p>}
+
+                  {msg.poll && (
+                    <div className="mt-2 space-y-1">
+                      {msg.poll.options.map((opt) => {
+                        const mine = opt.users?.includes(FAKE_CURRENT_USER.id)
+                        const others = msg.poll!.options
+                          .filter((o) => o.id !== opt.id && o.users?.includes(FAKE_CURRENT_USER.id))
+                          .map((o) => o.id)
+                        const ballot = msg.poll!.multiple
+                          ? (mine ? others : [...others, opt.id])
+                          : (mine ? [] : [opt.id])
+                        return (
+                          <button
+                            key={opt.id}
+                            disabled={msg.poll!.closed}
+                            onClick={() => sendFrame('vote', { room, message_id: msg.id, option_ids: ballot })}
+                            className={`w-full flex justify-between gap-4 text-sm px-3 py-1 rounded border ${mine ? 'border-emerald-400 bg-emerald-50 text-gray-800' : 'border-gray-200'} disabled:opacity-60`}
+                          >
+                            <span>{opt.text}</span>
+                            <span>{opt.votes}</span>
+                          </button>
+                        )
+                      })}
+                      <div className="text-xs opacity-70">
+                        {msg.poll.voters} {msg.poll.voters === 1 ? 'vote' : 'votes'}
+                        {msg.poll.anonymous && ' · anonymous'}
+                        {msg.poll.closed ? ' · closed' : msg.poll.closes_at && ` · closes ${format(new Date(msg.poll.closes_at), 'MMM d, h:mm a')}`}
+                      </div>
+                    </div>
+                  )}
                 </div>
                 
                 <span className="text-xs text-gray-400 mt-1.5 px-1">
                   {format(new Date(msg.timestamp), 'h:mm a')}
+                  {!msg.deleted && msg.kind !== 'announcement' && (
+                    <button onClick={() => setReplyTo(msg)} className="ml-2 hover:text-emerald-500">
+                      Reply
+                    </button>
+                  )}
                 </span>
+                {!msg.deleted && (
+                  <div className="flex gap-1 mt-1 px-1">
+                    {(msg.reactions ?? []).map((r) => {
+                      const mine = r.users.includes(FAKE_CURRENT_USER.id)
+                      return (
+                        <button
+                          key={r.emoji}
+                          onClick={() => sendFrame(mine ? 'unreact' : 'react', { room, message_id: msg.id, emoji: r.emoji })}
+                          className={`text-xs px-2 py-0.5 rounded-full border ${mine ? 'border-emerald-400 bg-emerald-50' : 'border-gray-200'}`}
+                        >
+                          {r.emoji} {r.count}
+                        </button>
+                      )
+                    })}
+                    {!msg.reactions?.some((r) => r.emoji === '👍') && (
+                      <button
+                        onClick={() => sendFrame('react', { room, message_id: msg.id, emoji: '👍' })}
+                        className="text-xs px-2 py-0.5 rounded-full border border-gray-200 text-gray-400 hover:text-gray-600"
+                      >
+                        👍
+                      </button>
+                    )}
+                  </div>
+                )}
+                {!!msg.reply_count && (
+                  <span className="text-xs text-emerald-600 px-1">
+                    {msg.reply_count} {msg.reply_count === 1 ? 'reply' : 'replies'}
+                    {msg.latest_reply && ` · last by ${msg.latest_reply.user.name}`}
+                  </span>
+                )}
               </div>
             </div>
           )
         })}
         <div ref={messagesEndRef} />
       </div>
+
+      {Object.keys(typingUsers).length > 0 && (
+        <div className="mt-2 text-xs text-gray-500">
+          {Object.values(typingUsers).join(', ')} {Object.keys(typingUsers).length === 1 ? 'is' : 'are'} typing…
+        </div>
+      )}
       
       {/* --- INPUT AREA --- */}
       <div className="mt-6">
+        {replyTo && (
+            <div className="mb-2 flex items-center gap-2 text-xs text-gray-600">
+                Replying to {replyTo.user.name}
+                <button onClick={() => setReplyTo(null)} className="text-gray-400 hover:text-red-500">
+                    <X size={14} />
+                </button>
+            </div>
+        )}
+
         {/* File Preview Badge */}
         {selectedFile && (
             <div className="mb-2 flex items-center gap-2 bg-gray-100 w-fit px-3 py-1 rounded-full border border-gray-300">
@@ -265,7 +494,15 @@ return { file_id };
                 placeholder="Type a message..."
                 className="flex-1 border border-gray-300 rounded-lg p-3 shadow-sm focus:outline-none focus:ring-2 focus:ring-purple-500"
                 value={newMessage}
-                onChange={(e) => setNewMessage(e.target.value)}
+                onChange={(e) => {
+                    setNewMessage(e.target.value)
+                    // The server drops typing after a few seconds without a refresh.
+                    const now = Date.now()
+                    if (e.target.value && now - lastTypingSent.current > 3000) {
+                        lastTypingSent.current = now
+                        ws.current?.send(JSON.stringify({ v: PROTOCOL_VERSION, type: 'typing.start', payload: { room } }))
+                    }
+                }}
                 disabled={isUploading}
             />
 
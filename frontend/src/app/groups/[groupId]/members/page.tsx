@@ -6,19 +6,28 @@ import {
   AlertCircle, 
   User, 
   ShieldCheck, 
-  ArrowUpCircle, 
-  ArrowDownCircle, 
   UserX,
-  Shield
+  Shield,
+  Crown,
+  Eye
 } from 'lucide-react';
 import { formatDistanceToNow } from 'date-fns';
 import axios from 'axios';
 
-const CURRENT_USER_ID = 'u1'; // 
-const CURRENT_USER_ROLE = 'admin'; 
+// Mirrors internal/permissions in core-service: owners and admins manage
+// members ranked below them, and nobody can be made owner.
+const ROLE_RANK = { viewer: 1, member: 2, moderator: 3, admin: 4, owner: 5 };
+const MANAGING_ROLES = ['owner', 'admin'];
+
+const canManage = (actorRole, targetRole) =>
+  MANAGING_ROLES.includes(actorRole) && ROLE_RANK[actorRole] > (ROLE_RANK[targetRole] || 0);
+
+const assignableRoles = (actorRole) =>
+  Object.keys(ROLE_RANK).filter(r => r !== 'owner' && ROLE_RANK[r] < ROLE_RANK[actorRole]);
 
 export default function GroupMembersPage({params}) {
   const [members, setMembers] = useState([]);
+  const [currentUserId, setCurrentUserId] = useState(null);
   const [loading, setLoading] = useState(true);
   const [error, setError] = useState(null);
   const [searchQuery, setSearchQuery] = useState('');
@@ -29,7 +38,8 @@ export default function GroupMembersPage({params}) {
     try {
       // Make sure to replace with your actual API host
       const res = await axios.get(`http://localhost:8080/groups/${groupId}/members`, { withCredentials: true });
-      setMembers(res.data.members);
+      setMembers(res.data.members || []);
+      setCurrentUserId(res.data.current_user_id);
       setError(null);
     } catch (err) {
       console.error(err);
@@ -39,15 +49,12 @@ export default function GroupMembersPage({params}) {
     }
   }, [groupId]); 
 
-  const updateUser = async (operation, userId) => {
+  const updateUser = async (operation, userId, role) => {
     let payload = {};
 
     switch (operation) {
-      case 'Promote':
-        payload = { role: 'admin' };
-        break;
-      case 'Demote':
-        payload = { role: 'member' };
+      case 'SetRole':
+        payload = { role };
         break;
       case 'Kick':
         payload = { status: 'kicked' };
@@ -62,10 +69,15 @@ export default function GroupMembersPage({params}) {
       await getMembers(); 
     } catch (err) {
       console.error(err);
-      setError(`Failed to ${operation.toLowerCase()} member.`);
+      setError(err.response?.data?.error || `Failed to ${operation === 'Kick' ? 'kick' : 'update'} member.`);
     }
   };
 
+  const currentUserRole = useMemo(
+    () => members.find(m => m.id === currentUserId)?.role,
+    [members, currentUserId]
+  );
+
   useEffect(() => {
     if (groupId) { 
       getMembers();
@@ -120,8 +132,9 @@ export default function GroupMembersPage({params}) {
           <MemberCard
             key={member.id}
             member={member}
-            isCurrentUser={member.id === CURRENT_USER_ID} 
-            currentUserIsAdmin={CURRENT_USER_ROLE === 'admin'} 
+            isCurrentUser={member.id === currentUserId}
+            manageable={canManage(currentUserRole, member.role)}
+            roleOptions={assignableRoles(currentUserRole)}
             onAction={updateUser}
           />
         ))}
@@ -155,7 +168,7 @@ export default function GroupMembersPage({params}) {
   );
 }
 
-function MemberCard({ member, isCurrentUser, currentUserIsAdmin, onAction }) {
+function MemberCard({ member, isCurrentUser, manageable, roleOptions, onAction }) {
   const { id, username, role, joined_at, avatarUrl, email } = member;
   const initial = username ? username.split(' ').map(n => n[0]).join('') : '?';
 
@@ -208,25 +221,20 @@ function MemberCard({ member, isCurrentUser, currentUserIsAdmin, onAction }) {
       </div>
 
       {/* Right Side: Admin Controls */}
-      {currentUserIsAdmin && !isCurrentUser && (
+      {manageable && !isCurrentUser && (
         <div className="flex gap-2 w-full sm:w-auto mt-4 sm:mt-0">
-          {role === 'member' ? (
-            <button
-              onClick={() => onAction('Promote', id)}
-              className="flex-1 sm:flex-none flex items-center justify-center gap-1.5 px-3 py-2 text-sm font-medium bg-emerald-100 text-emerald-700 rounded-lg hover:bg-emerald-200 transition-colors"
+          <label className="flex-1 sm:flex-none flex items-center gap-1.5 px-3 py-2 text-sm font-medium bg-purple-50 text-purple-700 rounded-lg">
+            <Shield size={16} />
+            <select
+              value={role}
+              onChange={(e) => onAction('SetRole', id, e.target.value)}
+              className="bg-transparent focus:outline-none capitalize"
             >
-              <ArrowUpCircle size={16} />
-              Promote
-            </button>
-          ) : (
-            <button
-              onClick={() => onAction('Demote', id)}
-              className="flex-1 sm:flex-none flex items-center justify-center gap-1.5 px-3 py-2 text-sm font-medium bg-amber-100 text-amber-700 rounded-lg hover:bg-amber-200 transition-colors"
-            >
-              <ArrowDownCircle size={16} />
-              Demote
-            </button>
-          )}
+              {roleOptions.map(r => (
+                <option key={r} value={r}>{r}</option>
+              ))}
+            </select>
+          </label>
           <button
             onClick={() => onAction('Kick', id)}
             className="flex-1 sm:flex-none flex items-center justify-center gap-1.5 px-3 py-2 text-sm font-medium bg-red-100 text-red-700 rounded-lg hover:bg-red-200 transition-colors"
@@ -241,19 +249,20 @@ function MemberCard({ member, isCurrentUser, currentUserIsAdmin, onAction }) {
 }
 
 
+const ROLE_BADGES = {
+  owner: { label: 'Owner', Icon: Crown, className: 'text-purple-700 font-medium' },
+  admin: { label: 'Admin', Icon: ShieldCheck, className: 'text-emerald-700 font-medium' },
+  moderator: { label: 'Moderator', Icon: Shield, className: 'text-sky-700 font-medium' },
+  member: { label: 'Member', Icon: User, className: 'text-gray-600' },
+  viewer: { label: 'Viewer', Icon: Eye, className: 'text-gray-500' },
+};
+
 function RoleBadge({ role }) {
-  if (role === 'admin') {
-    return (
-      <span className="inline-flex items-center gap-1.5 text-emerald-700">
-        <ShieldCheck size={14} />
-        <span className="font-medium">Admin</span>
-      </span>
-    );
-  }
+  const { label, Icon, className } = ROLE_BADGES[role] || ROLE_BADGES.member;
   return (
-    <span className="inline-flex items-center gap-1.5 text-gray-600">
-      <User size={14} />
-      <span>Member</span>
+    <span className={`inline-flex items-center gap-1.5 ${className}`}>
+      <Icon size={14} />
+      <span>{label}</span>
     </span>
   );
 }